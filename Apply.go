@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"runtime"
+	"sync"
+)
+
+// Apply calls f on every row (axis == 0) or column (axis == 1) and returns the results in label order
+func (t *Table) Apply(axis _Axis, f func([]interface{}) interface{}) []interface{} {
+	return t.ApplyParallel(axis, f, 1)
+}
+
+// ApplyParallel is the same as Apply but splits the calls to f across workers goroutines. If workers is less than 1, runtime.NumCPU() is used
+func (t *Table) ApplyParallel(axis _Axis, f func([]interface{}) interface{}, workers int) []interface{} {
+	vals := getValsOrient(axis, t.Vals)
+	out := make([]interface{}, len(vals))
+	parallelFor(len(vals), workers, func(i int) {
+		out[i] = f(vals[i])
+	})
+	return out
+}
+
+// ApplyMap returns a new table with f called on every cell
+func (t *Table) ApplyMap(f func(interface{}) interface{}) *Table {
+	return t.ApplyMapParallel(f, 1)
+}
+
+// ApplyMapParallel is the same as ApplyMap but splits the rows across workers goroutines. If workers is less than 1, runtime.NumCPU() is used
+func (t *Table) ApplyMapParallel(f func(interface{}) interface{}, workers int) *Table {
+	t0 := t.Copy(false)
+	parallelFor(len(t.Vals), workers, func(i int) {
+		row := make([]interface{}, len(t.Vals[i]))
+		for j, cell := range t.Vals[i] {
+			row[j] = f(cell)
+		}
+		t0.Vals[i] = row
+	})
+	return t0
+}
+
+// Transform returns a new table of the same shape with every row (axis == 0) or column (axis == 1) replaced by the result of f. f must return a slice of the same length as the one passed in
+func (t *Table) Transform(axis _Axis, f func([]interface{}) []interface{}) *Table {
+	return t.TransformParallel(axis, f, 1)
+}
+
+// TransformParallel is the same as Transform but splits the calls to f across workers goroutines. If workers is less than 1, runtime.NumCPU() is used
+func (t *Table) TransformParallel(axis _Axis, f func([]interface{}) []interface{}, workers int) *Table {
+	vals := getValsOrient(axis, t.Vals)
+	out := make([][]interface{}, len(vals))
+	parallelFor(len(vals), workers, func(i int) {
+		out[i] = f(copy1D(vals[i]))
+	})
+	for i := range out {
+		if len(out[i]) != len(vals[i]) {
+			log.Fatalln(errors.New("Transform must return a slice of the same length"))
+		}
+	}
+
+	t0 := t.Copy(false)
+	t0.Vals = getValsOrient(axis, out)
+	return t0
+}
+
+// parallelFor calls f for every i in [0, n), splitting the range into contiguous chunks across workers goroutines
+func parallelFor(n int, workers int, f func(i int)) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				f(i)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,1253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// ArrowType is the logical type of an Arrow column
+type ArrowType uint8
+
+const (
+	ArrowNull ArrowType = iota
+	ArrowBool
+	ArrowInt8
+	ArrowInt16
+	ArrowInt32
+	ArrowInt64
+	ArrowUint8
+	ArrowUint16
+	ArrowUint32
+	ArrowUint64
+	ArrowFloat32
+	ArrowFloat64
+	ArrowString
+	ArrowBinary
+	ArrowTimestamp // int64 since the unix epoch in Unit
+	ArrowDate      // int32 days since the unix epoch
+	ArrowDuration  // int64 in Unit
+)
+
+// ArrowTimeUnit is the resolution of ArrowTimestamp and ArrowDuration values
+type ArrowTimeUnit uint8
+
+const (
+	ArrowSecond ArrowTimeUnit = iota
+	ArrowMillisecond
+	ArrowMicrosecond
+	ArrowNanosecond
+)
+
+// ArrowField describes one column of an ArrowRecord
+type ArrowField struct {
+	Name     string
+	Type     ArrowType
+	Nullable bool
+	Unit     ArrowTimeUnit // ArrowTimestamp and ArrowDuration only
+	Timezone string        // ArrowTimestamp only
+
+	largeOffsets bool // ArrowString and ArrowBinary read with 64 bit offsets
+}
+
+// ArrowColumn holds the buffers of one column in the Arrow columnar layout
+type ArrowColumn struct {
+	NullCount int
+	Validity  []byte  // bitmap with a set bit for every valid value, nil when there are no nulls
+	Offsets   []int64 // start of every value in Data plus the end of the last one, ArrowString and ArrowBinary only
+	Data      []byte  // little endian fixed width values, bit packed bools, or the bytes of every string
+}
+
+// ArrowRecord is an Arrow record batch: a schema along with one ArrowColumn per field
+type ArrowRecord struct {
+	Fields   []ArrowField
+	Metadata map[string]string
+	Length   int
+	Columns  []ArrowColumn
+}
+
+var arrowMagic = []byte("ARROW1")
+
+// ToArrowRecord converts the table to an ArrowRecord. The index becomes the first column and its name, along with Table.Header.Header, is kept in the schema metadata so FromArrowRecord can restore it. Each column takes its type from its first non-missing value: bools, all sized ints and uints (int as int64), floats, strings, []byte, time.Time (as a UTC timestamp in microseconds) and time.Duration are supported and other values are converted to strings using DefaultFormats
+func (t *Table) ToArrowRecord() (*ArrowRecord, error) {
+	header := mergeIndex1D(t.Index.Header, t.Header.Slice)
+	cols := make([][]interface{}, len(header))
+	if len(t.Vals) > 0 {
+		cols = getValsOrient(1, mergeIndex2D(t.Index.Slice, t.Vals))
+	}
+
+	rec := &ArrowRecord{
+		Metadata: map[string]string{
+			indexMetadataKey:  DefaultFormats.Format(nil, t.Index.Header),
+			headerMetadataKey: DefaultFormats.Format(nil, t.Header.Header)},
+		Length: len(t.Vals)}
+	for i, label := range header {
+		field, col, err := arrowEncodeColumn(DefaultFormats.Format(nil, label), cols[i])
+		if err != nil {
+			return nil, err
+		}
+		rec.Fields = append(rec.Fields, field)
+		rec.Columns = append(rec.Columns, col)
+	}
+	return rec, nil
+}
+
+// arrowEncodeColumn infers the field of a column and builds its buffers
+func arrowEncodeColumn(name string, vals []interface{}) (ArrowField, ArrowColumn, error) {
+	field := ArrowField{Name: name, Type: ArrowNull}
+	var sample interface{}
+	for _, val := range vals {
+		if !IsNA(val) {
+			sample = val
+			break
+		}
+	}
+	switch sample.(type) {
+	case nil:
+		return field, ArrowColumn{NullCount: len(vals)}, nil
+	case bool:
+		field.Type = ArrowBool
+	case int8:
+		field.Type = ArrowInt8
+	case int16:
+		field.Type = ArrowInt16
+	case int32:
+		field.Type = ArrowInt32
+	case int, int64:
+		field.Type = ArrowInt64
+	case uint8:
+		field.Type = ArrowUint8
+	case uint16:
+		field.Type = ArrowUint16
+	case uint32:
+		field.Type = ArrowUint32
+	case uint, uint64:
+		field.Type = ArrowUint64
+	case float32:
+		field.Type = ArrowFloat32
+	case float64:
+		field.Type = ArrowFloat64
+	case []byte:
+		field.Type = ArrowBinary
+	case time.Time:
+		field.Type = ArrowTimestamp
+		field.Unit = ArrowMicrosecond
+		field.Timezone = "UTC"
+	case time.Duration:
+		field.Type = ArrowDuration
+		field.Unit = ArrowNanosecond
+	default:
+		field.Type = ArrowString
+	}
+
+	var col ArrowColumn
+	sampleType := reflect.TypeOf(sample)
+	validity := make([]byte, (len(vals)+7)/8)
+	if field.Type == ArrowString || field.Type == ArrowBinary {
+		col.Offsets = make([]int64, 1, len(vals)+1)
+	}
+	for i, val := range vals {
+		if IsNA(val) {
+			col.NullCount++
+			if field.Type == ArrowBool {
+				col.Data = appendBit(col.Data, i, false)
+			} else if col.Offsets != nil {
+				col.Offsets = append(col.Offsets, int64(len(col.Data)))
+			} else {
+				col.Data = append(col.Data, make([]byte, arrowWidth(field.Type))...)
+			}
+			continue
+		}
+		validity[i/8] |= 1 << (i % 8)
+
+		ok := true
+		switch field.Type {
+		case ArrowString:
+			col.Data = append(col.Data, DefaultFormats.Format(nil, val)...)
+			col.Offsets = append(col.Offsets, int64(len(col.Data)))
+		case ArrowBinary:
+			var b []byte
+			if b, ok = val.([]byte); ok {
+				col.Data = append(col.Data, b...)
+				col.Offsets = append(col.Offsets, int64(len(col.Data)))
+			}
+		case ArrowBool:
+			var b bool
+			if b, ok = val.(bool); ok {
+				col.Data = appendBit(col.Data, i, b)
+			}
+		case ArrowTimestamp:
+			var tm time.Time
+			if tm, ok = val.(time.Time); ok {
+				col.Data = binary.LittleEndian.AppendUint64(col.Data, uint64(tm.UnixMicro()))
+			}
+		case ArrowFloat32:
+			var f float32
+			if f, ok = val.(float32); ok {
+				col.Data = binary.LittleEndian.AppendUint32(col.Data, math.Float32bits(f))
+			}
+		case ArrowFloat64:
+			var f float64
+			if f, ok = val.(float64); ok {
+				col.Data = binary.LittleEndian.AppendUint64(col.Data, math.Float64bits(f))
+			}
+		default: // integers and durations
+			ok = reflect.TypeOf(val) == sampleType || (field.Type == ArrowInt64 && isInt64Like(val)) || (field.Type == ArrowUint64 && isUint64Like(val))
+			if ok {
+				col.Data = appendInt(col.Data, val, arrowWidth(field.Type))
+			}
+		}
+		if !ok {
+			return field, col, fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+		}
+	}
+	if col.NullCount > 0 {
+		col.Validity = validity
+		field.Nullable = true
+	}
+	return field, col, nil
+}
+
+func isInt64Like(val interface{}) bool {
+	switch val.(type) {
+	case int, int64:
+		return true
+	}
+	return false
+}
+
+func isUint64Like(val interface{}) bool {
+	switch val.(type) {
+	case uint, uint64:
+		return true
+	}
+	return false
+}
+
+// appendBit sets bit i of a bitmap, growing it as needed
+func appendBit(bitmap []byte, i int, b bool) []byte {
+	for len(bitmap) <= i/8 {
+		bitmap = append(bitmap, 0)
+	}
+	if b {
+		bitmap[i/8] |= 1 << (i % 8)
+	}
+	return bitmap
+}
+
+// appendInt appends any Go integer as a little endian integer of width bytes
+func appendInt(buf []byte, val interface{}, width int) []byte {
+	var u uint64
+	switch v := val.(type) {
+	case int:
+		u = uint64(v)
+	case int8:
+		u = uint64(v)
+	case int16:
+		u = uint64(v)
+	case int32:
+		u = uint64(v)
+	case int64:
+		u = uint64(v)
+	case uint:
+		u = uint64(v)
+	case uint8:
+		u = uint64(v)
+	case uint16:
+		u = uint64(v)
+	case uint32:
+		u = uint64(v)
+	case uint64:
+		u = v
+	case time.Duration:
+		u = uint64(v)
+	}
+	switch width {
+	case 1:
+		return append(buf, byte(u))
+	case 2:
+		return binary.LittleEndian.AppendUint16(buf, uint16(u))
+	case 4:
+		return binary.LittleEndian.AppendUint32(buf, uint32(u))
+	}
+	return binary.LittleEndian.AppendUint64(buf, u)
+}
+
+// arrowWidth returns the byte width of a fixed width type, 0 for variable width and bit packed types
+func arrowWidth(typ ArrowType) int {
+	switch typ {
+	case ArrowInt8, ArrowUint8:
+		return 1
+	case ArrowInt16, ArrowUint16:
+		return 2
+	case ArrowInt32, ArrowUint32, ArrowFloat32, ArrowDate:
+		return 4
+	case ArrowInt64, ArrowUint64, ArrowFloat64, ArrowTimestamp, ArrowDuration:
+		return 8
+	}
+	return 0
+}
+
+// FromArrowRecord converts an ArrowRecord to a Table. If the schema metadata names an index column, it becomes the index, otherwise a sequential index is created. Values become the Go type matching their Arrow type, timestamps and dates become time.Time in UTC and nulls become nil
+func FromArrowRecord(rec *ArrowRecord) (*Table, error) {
+	if len(rec.Columns) != len(rec.Fields) {
+		return nil, errors.New("Number of columns does not match number of fields")
+	}
+	cols := make([][]interface{}, len(rec.Fields))
+	for i, field := range rec.Fields {
+		vals, err := arrowDecodeColumn(field, rec.Columns[i], rec.Length)
+		if err != nil {
+			return nil, fmt.Errorf("Column %s: %v", field.Name, err)
+		}
+		cols[i] = vals
+	}
+
+	header := make([]interface{}, len(rec.Fields))
+	for i, field := range rec.Fields {
+		header[i] = field.Name
+	}
+	t := &Table{Index: CreateNumMS(0, rec.Length)}
+	if name, ok := rec.Metadata[indexMetadataKey]; ok && len(header) > 0 && header[0] == name {
+		for _, val := range cols[0] {
+			if val != nil && !reflect.TypeOf(val).Comparable() {
+				return nil, fmt.Errorf("Index %s has %T values, which cannot be labels", name, val)
+			}
+		}
+		t.Index = CreateMS(cols[0], name)
+		header = header[1:]
+		cols = cols[1:]
+	}
+	t.Header = CreateGenMS(1, header)
+	if name, ok := rec.Metadata[headerMetadataKey]; ok {
+		t.Header.Header = name
+	}
+	t.Vals = make([][]interface{}, rec.Length)
+	for i := range t.Vals {
+		t.Vals[i] = make([]interface{}, len(cols))
+		for j := range cols {
+			t.Vals[i][j] = cols[j][i]
+		}
+	}
+	return t, nil
+}
+
+// arrowDecodeColumn converts the buffers of a column to Go values
+func arrowDecodeColumn(field ArrowField, col ArrowColumn, length int) ([]interface{}, error) {
+	vals := make([]interface{}, length)
+	if field.Type == ArrowNull {
+		return vals, nil
+	}
+	width := arrowWidth(field.Type)
+	switch {
+	case field.Type == ArrowBool:
+		if len(col.Data) < (length+7)/8 {
+			return nil, errors.New("Data buffer is too short")
+		}
+	case field.Type == ArrowString || field.Type == ArrowBinary:
+		if len(col.Offsets) < length+1 {
+			return nil, errors.New("Offsets buffer is too short")
+		}
+	case len(col.Data) < width*length:
+		return nil, errors.New("Data buffer is too short")
+	}
+	if col.Validity != nil && len(col.Validity) < (length+7)/8 {
+		return nil, errors.New("Validity buffer is too short")
+	}
+
+	for i := range vals {
+		if col.Validity != nil && col.Validity[i/8]>>(i%8)&1 == 0 {
+			continue
+		}
+		data := col.Data[i*width:]
+		switch field.Type {
+		case ArrowBool:
+			vals[i] = col.Data[i/8]>>(i%8)&1 == 1
+		case ArrowInt8:
+			vals[i] = int8(data[0])
+		case ArrowInt16:
+			vals[i] = int16(binary.LittleEndian.Uint16(data))
+		case ArrowInt32:
+			vals[i] = int32(binary.LittleEndian.Uint32(data))
+		case ArrowInt64:
+			vals[i] = int64(binary.LittleEndian.Uint64(data))
+		case ArrowUint8:
+			vals[i] = data[0]
+		case ArrowUint16:
+			vals[i] = binary.LittleEndian.Uint16(data)
+		case ArrowUint32:
+			vals[i] = binary.LittleEndian.Uint32(data)
+		case ArrowUint64:
+			vals[i] = binary.LittleEndian.Uint64(data)
+		case ArrowFloat32:
+			vals[i] = math.Float32frombits(binary.LittleEndian.Uint32(data))
+		case ArrowFloat64:
+			vals[i] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+		case ArrowString, ArrowBinary:
+			start, end := col.Offsets[i], col.Offsets[i+1]
+			if start < 0 || start > end || end > int64(len(col.Data)) {
+				return nil, errors.New("Invalid offsets")
+			}
+			if field.Type == ArrowString {
+				vals[i] = string(col.Data[start:end])
+			} else {
+				vals[i] = append([]byte(nil), col.Data[start:end]...)
+			}
+		case ArrowTimestamp:
+			vals[i] = time.Unix(0, arrowNanos(int64(binary.LittleEndian.Uint64(data)), field.Unit)).UTC()
+		case ArrowDate:
+			vals[i] = time.Unix(int64(int32(binary.LittleEndian.Uint32(data)))*86400, 0).UTC()
+		case ArrowDuration:
+			vals[i] = time.Duration(arrowNanos(int64(binary.LittleEndian.Uint64(data)), field.Unit))
+		default:
+			return nil, fmt.Errorf("Unsupported arrow type %d", field.Type)
+		}
+	}
+	return vals, nil
+}
+
+// arrowNanos converts a timestamp or duration in unit to nanoseconds
+func arrowNanos(v int64, unit ArrowTimeUnit) int64 {
+	switch unit {
+	case ArrowSecond:
+		return v * int64(time.Second)
+	case ArrowMillisecond:
+		return v * int64(time.Millisecond)
+	case ArrowMicrosecond:
+		return v * int64(time.Microsecond)
+	}
+	return v
+}
+
+// flatbuffer union type ids of the Arrow schema
+const (
+	arrowTypeNull          = 1
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeBinary        = 4
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+	arrowTypeDate          = 8
+	arrowTypeTimestamp     = 10
+	arrowTypeDuration      = 18
+	arrowTypeLargeBinary   = 19
+	arrowTypeLargeUtf8     = 20
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowMetadataV5 = 4
+)
+
+// WriteArrowStream writes the table to w in the Arrow IPC streaming format as a single record batch
+func WriteArrowStream(w io.Writer, t *Table) error {
+	rec, err := t.ToArrowRecord()
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	writeArrowMessage(ew, arrowSchemaMessage(rec), nil)
+	meta, body := arrowBatchMessage(rec)
+	writeArrowMessage(ew, meta, body)
+	ew.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}) // end of stream
+	return ew.err
+}
+
+// ReadArrowStream reads a Table from r in the Arrow IPC streaming format. Every record batch of the stream is appended to the table
+func ReadArrowStream(r io.Reader) (*Table, error) {
+	var rec *ArrowRecord
+	for {
+		msg, body, err := readArrowMessage(r)
+		if err != nil {
+			return nil, err
+		}
+		if msg.buf == nil { // end of stream
+			break
+		}
+		if rec, err = applyArrowMessage(rec, msg, body); err != nil {
+			return nil, err
+		}
+	}
+	if rec == nil {
+		return nil, errors.New("Stream has no schema")
+	}
+	return FromArrowRecord(rec)
+}
+
+// WriteArrowFile writes the table to a file in the Arrow IPC file format as a single record batch
+func WriteArrowFile(path string, t *Table) error {
+	rec, err := t.ToArrowRecord()
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(arrowMagic)
+	buf.Write([]byte{0, 0})
+	writeArrowMessage(&buf, arrowSchemaMessage(rec), nil)
+	offset := int64(buf.Len())
+	meta, body := arrowBatchMessage(rec)
+	metaLen := writeArrowMessage(&buf, meta, body)
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+
+	footer := arrowFooter(rec, offset, metaLen, int64(len(body)))
+	buf.Write(footer)
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	buf.Write(arrowMagic)
+
+	if _, err := buf.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadArrowFile reads a Table from a file in the Arrow IPC file format. Every record batch of the file is appended to the table
+func ReadArrowFile(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 18 || !bytes.Equal(data[:6], arrowMagic) || !bytes.Equal(data[len(data)-6:], arrowMagic) {
+		return nil, errors.New("File is not arrow")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	if footerLen > len(data)-18 {
+		return nil, errors.New("Invalid arrow footer length")
+	}
+	footer := fbRoot(data[len(data)-10-footerLen : len(data)-10])
+
+	schema, ok := footer.table(1)
+	if !ok {
+		if err := footer.err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("Footer has no schema")
+	}
+	rec, err := arrowReadSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < footer.vectorLen(3); i++ {
+		block := footer.structAt(3, i, 24)
+		offset := block.int64At(0)
+		if err := footer.err(); err != nil {
+			return nil, err
+		}
+		if offset < 0 || offset > int64(len(data)) {
+			return nil, errors.New("Invalid record batch offset")
+		}
+		msg, body, err := readArrowMessage(bytes.NewReader(data[offset:]))
+		if err != nil {
+			return nil, err
+		}
+		if msg.buf == nil {
+			return nil, errors.New("Record batch block points at end of stream")
+		}
+		if rec, err = applyArrowMessage(rec, msg, body); err != nil {
+			return nil, err
+		}
+	}
+	return FromArrowRecord(rec)
+}
+
+// applyArrowMessage adds a schema or record batch message to the record being read
+func applyArrowMessage(rec *ArrowRecord, msg fbTable, body []byte) (*ArrowRecord, error) {
+	header, ok := msg.table(2)
+	if !ok {
+		return nil, errors.New("Message has no header")
+	}
+	typ := msg.byteField(1, 0)
+	if err := msg.err(); err != nil {
+		return nil, err
+	}
+	switch typ {
+	case arrowHeaderSchema:
+		if rec != nil {
+			return nil, errors.New("Unexpected second schema")
+		}
+		return arrowReadSchema(header)
+	case arrowHeaderRecordBatch:
+		if rec == nil {
+			return nil, errors.New("Record batch before schema")
+		}
+		return rec, arrowReadBatch(rec, header, body)
+	}
+	return nil, errors.New("Dictionary batches and tensors are not supported")
+}
+
+// writeArrowMessage writes an encapsulated message: continuation marker, metadata length, padded metadata and body. It returns the length of everything but the body
+func writeArrowMessage(w io.Writer, meta []byte, body []byte) int32 {
+	padded := (len(meta) + 7) &^ 7
+	prefix := binary.LittleEndian.AppendUint32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(padded))
+	w.Write(prefix)
+	w.Write(meta)
+	w.Write(make([]byte, padded-len(meta)))
+	w.Write(body)
+	return int32(8 + padded)
+}
+
+// readArrowMessage reads an encapsulated message. It returns a nil message at the end of the stream
+func readArrowMessage(r io.Reader) (fbTable, []byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.EOF {
+			return fbTable{}, nil, nil
+		}
+		return fbTable{}, nil, err
+	}
+	size := binary.LittleEndian.Uint32(prefix[:])
+	if size == 0xffffffff {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			return fbTable{}, nil, err
+		}
+		size = binary.LittleEndian.Uint32(prefix[:])
+	}
+	if size == 0 {
+		return fbTable{}, nil, nil
+	}
+	if size > 1<<30 {
+		return fbTable{}, nil, errors.New("Invalid arrow message length")
+	}
+	meta, err := readArrowBytes(r, int64(size))
+	if err != nil {
+		return fbTable{}, nil, err
+	}
+	msg := fbRoot(meta)
+	bodyLen := msg.int64Field(3, 0)
+	if err := msg.err(); err != nil {
+		return fbTable{}, nil, err
+	}
+	if bodyLen < 0 {
+		return fbTable{}, nil, errors.New("Invalid arrow body length")
+	}
+	body, err := readArrowBytes(r, bodyLen)
+	if err != nil {
+		return fbTable{}, nil, err
+	}
+	return msg, body, nil
+}
+
+// readArrowBytes reads exactly n bytes from r, growing the buffer as they arrive so a corrupt length cannot allocate more than r holds
+func readArrowBytes(r io.Reader, n int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, n))
+	if err == nil && int64(len(b)) < n {
+		err = io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// arrowSchemaMessage builds the flatbuffer Message holding the schema of a record
+func arrowSchemaMessage(rec *ArrowRecord) []byte {
+	b := flatbuffers.NewBuilder(1024)
+	schema := arrowBuildSchema(b, rec)
+	b.StartObject(5)
+	b.PrependInt16Slot(0, arrowMetadataV5, 0)
+	b.PrependByteSlot(1, arrowHeaderSchema, 0)
+	b.PrependUOffsetTSlot(2, schema, 0)
+	b.Finish(b.EndObject())
+	return b.FinishedBytes()
+}
+
+// arrowBuildSchema adds a Schema table to b
+func arrowBuildSchema(b *flatbuffers.Builder, rec *ArrowRecord) flatbuffers.UOffsetT {
+	fields := make([]flatbuffers.UOffsetT, len(rec.Fields))
+	for i, field := range rec.Fields {
+		name := b.CreateString(field.Name)
+		typeID, typ := arrowBuildType(b, field)
+		b.StartVector(4, 0, 4)
+		children := b.EndVector(0)
+		b.StartObject(7)
+		b.PrependUOffsetTSlot(0, name, 0)
+		b.PrependBoolSlot(1, field.Nullable, false)
+		b.PrependByteSlot(2, typeID, 0)
+		b.PrependUOffsetTSlot(3, typ, 0)
+		b.PrependUOffsetTSlot(5, children, 0)
+		fields[i] = b.EndObject()
+	}
+	fieldVec := fbVectorOfTables(b, fields)
+
+	keys := make([]string, 0, len(rec.Metadata))
+	for key := range rec.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]flatbuffers.UOffsetT, len(keys))
+	for i, key := range keys {
+		k := b.CreateString(key)
+		v := b.CreateString(rec.Metadata[key])
+		b.StartObject(2)
+		b.PrependUOffsetTSlot(0, k, 0)
+		b.PrependUOffsetTSlot(1, v, 0)
+		pairs[i] = b.EndObject()
+	}
+	metaVec := fbVectorOfTables(b, pairs)
+
+	b.StartObject(4)
+	b.PrependUOffsetTSlot(1, fieldVec, 0)
+	b.PrependUOffsetTSlot(2, metaVec, 0)
+	return b.EndObject()
+}
+
+// arrowBuildType adds the type table of a field to b and returns its union type id
+func arrowBuildType(b *flatbuffers.Builder, field ArrowField) (byte, flatbuffers.UOffsetT) {
+	intType := func(bits int32, signed bool) (byte, flatbuffers.UOffsetT) {
+		b.StartObject(2)
+		b.PrependInt32Slot(0, bits, 0)
+		b.PrependBoolSlot(1, signed, false)
+		return arrowTypeInt, b.EndObject()
+	}
+	empty := func(id byte) (byte, flatbuffers.UOffsetT) {
+		b.StartObject(0)
+		return id, b.EndObject()
+	}
+	switch field.Type {
+	case ArrowBool:
+		return empty(arrowTypeBool)
+	case ArrowInt8:
+		return intType(8, true)
+	case ArrowInt16:
+		return intType(16, true)
+	case ArrowInt32:
+		return intType(32, true)
+	case ArrowInt64:
+		return intType(64, true)
+	case ArrowUint8:
+		return intType(8, false)
+	case ArrowUint16:
+		return intType(16, false)
+	case ArrowUint32:
+		return intType(32, false)
+	case ArrowUint64:
+		return intType(64, false)
+	case ArrowFloat32, ArrowFloat64:
+		precision := int16(1)
+		if field.Type == ArrowFloat64 {
+			precision = 2
+		}
+		b.StartObject(1)
+		b.PrependInt16Slot(0, precision, 0)
+		return arrowTypeFloatingPoint, b.EndObject()
+	case ArrowString:
+		return empty(arrowTypeUtf8)
+	case ArrowBinary:
+		return empty(arrowTypeBinary)
+	case ArrowTimestamp:
+		var tz flatbuffers.UOffsetT
+		if field.Timezone != "" {
+			tz = b.CreateString(field.Timezone)
+		}
+		b.StartObject(2)
+		b.PrependInt16Slot(0, int16(field.Unit), 0)
+		if tz != 0 {
+			b.PrependUOffsetTSlot(1, tz, 0)
+		}
+		return arrowTypeTimestamp, b.EndObject()
+	case ArrowDate:
+		b.StartObject(1)
+		b.PrependInt16Slot(0, 0, 1) // days
+		return arrowTypeDate, b.EndObject()
+	case ArrowDuration:
+		b.StartObject(1)
+		b.PrependInt16Slot(0, int16(field.Unit), 1)
+		return arrowTypeDuration, b.EndObject()
+	}
+	return empty(arrowTypeNull)
+}
+
+// arrowBatchMessage builds the flatbuffer Message of a record batch along with its body
+func arrowBatchMessage(rec *ArrowRecord) ([]byte, []byte) {
+	type buffer struct{ offset, length int64 }
+	var body []byte
+	var buffers []buffer
+	addBuffer := func(data []byte) {
+		buffers = append(buffers, buffer{int64(len(body)), int64(len(data))})
+		body = append(body, data...)
+		body = append(body, make([]byte, (8-len(body)%8)%8)...)
+	}
+	for i, field := range rec.Fields {
+		col := rec.Columns[i]
+		if field.Type == ArrowNull {
+			continue
+		}
+		addBuffer(col.Validity)
+		if col.Offsets != nil {
+			offsets := make([]byte, 0, 4*len(col.Offsets))
+			for _, offset := range col.Offsets {
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(offset))
+			}
+			addBuffer(offsets)
+		}
+		addBuffer(col.Data)
+	}
+
+	b := flatbuffers.NewBuilder(1024)
+	b.StartVector(16, len(rec.Columns), 8)
+	for i := len(rec.Columns) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(int64(rec.Columns[i].NullCount))
+		b.PrependInt64(int64(rec.Length))
+	}
+	nodes := b.EndVector(len(rec.Columns))
+	b.StartVector(16, len(buffers), 8)
+	for i := len(buffers) - 1; i >= 0; i-- {
+		b.Prep(8, 16)
+		b.PrependInt64(buffers[i].length)
+		b.PrependInt64(buffers[i].offset)
+	}
+	bufferVec := b.EndVector(len(buffers))
+	b.StartObject(4)
+	b.PrependInt64Slot(0, int64(rec.Length), 0)
+	b.PrependUOffsetTSlot(1, nodes, 0)
+	b.PrependUOffsetTSlot(2, bufferVec, 0)
+	batch := b.EndObject()
+
+	b.StartObject(5)
+	b.PrependInt16Slot(0, arrowMetadataV5, 0)
+	b.PrependByteSlot(1, arrowHeaderRecordBatch, 0)
+	b.PrependUOffsetTSlot(2, batch, 0)
+	b.PrependInt64Slot(3, int64(len(body)), 0)
+	b.Finish(b.EndObject())
+	return b.FinishedBytes(), body
+}
+
+// arrowFooter builds the flatbuffer Footer of a file holding a single record batch
+func arrowFooter(rec *ArrowRecord, offset int64, metaLen int32, bodyLen int64) []byte {
+	b := flatbuffers.NewBuilder(1024)
+	schema := arrowBuildSchema(b, rec)
+	b.StartVector(24, 0, 8)
+	dictionaries := b.EndVector(0)
+	b.StartVector(24, 1, 8)
+	b.Prep(8, 24)
+	b.PrependInt64(bodyLen)
+	b.Pad(4)
+	b.PrependInt32(metaLen)
+	b.PrependInt64(offset)
+	batches := b.EndVector(1)
+	b.StartObject(5)
+	b.PrependInt16Slot(0, arrowMetadataV5, 0)
+	b.PrependUOffsetTSlot(1, schema, 0)
+	b.PrependUOffsetTSlot(2, dictionaries, 0)
+	b.PrependUOffsetTSlot(3, batches, 0)
+	b.Finish(b.EndObject())
+	return b.FinishedBytes()
+}
+
+// arrowReadSchema decodes a Schema table into an empty record
+func arrowReadSchema(schema fbTable) (*ArrowRecord, error) {
+	rec := &ArrowRecord{Metadata: make(map[string]string)}
+	for i := 0; i < schema.vectorLen(2); i++ {
+		kv := schema.tableAt(2, i)
+		rec.Metadata[kv.stringField(0)] = kv.stringField(1)
+	}
+	for i := 0; i < schema.vectorLen(1); i++ {
+		f := schema.tableAt(1, i)
+		if f.vectorLen(5) > 0 {
+			return nil, errors.New("Nested arrow types are not supported")
+		}
+		if _, ok := f.table(4); ok {
+			return nil, errors.New("Dictionary encoded arrow fields are not supported")
+		}
+		field := ArrowField{Name: f.stringField(0), Nullable: f.boolField(1, false)}
+		typ, _ := f.table(3)
+		switch f.byteField(2, 0) {
+		case arrowTypeNull:
+			field.Type = ArrowNull
+		case arrowTypeBool:
+			field.Type = ArrowBool
+		case arrowTypeInt:
+			signed := typ.boolField(1, false)
+			switch typ.int32Field(0, 0) {
+			case 8:
+				field.Type = ArrowInt8
+			case 16:
+				field.Type = ArrowInt16
+			case 32:
+				field.Type = ArrowInt32
+			case 64:
+				field.Type = ArrowInt64
+			default:
+				return nil, errors.New("Invalid arrow int width")
+			}
+			if !signed {
+				field.Type += ArrowUint8 - ArrowInt8
+			}
+		case arrowTypeFloatingPoint:
+			switch typ.int16Field(0, 0) {
+			case 1:
+				field.Type = ArrowFloat32
+			case 2:
+				field.Type = ArrowFloat64
+			default:
+				return nil, errors.New("Half precision floats are not supported")
+			}
+		case arrowTypeUtf8, arrowTypeLargeUtf8:
+			field.Type = ArrowString
+		case arrowTypeBinary, arrowTypeLargeBinary:
+			field.Type = ArrowBinary
+		case arrowTypeTimestamp:
+			field.Type = ArrowTimestamp
+			field.Unit = ArrowTimeUnit(typ.int16Field(0, 0))
+			field.Timezone = typ.stringField(1)
+		case arrowTypeDate:
+			field.Type = ArrowDate
+			if typ.int16Field(0, 1) != 0 { // milliseconds are read as timestamps
+				field.Type = ArrowTimestamp
+				field.Unit = ArrowMillisecond
+			}
+		case arrowTypeDuration:
+			field.Type = ArrowDuration
+			field.Unit = ArrowTimeUnit(typ.int16Field(0, 1))
+		default:
+			return nil, fmt.Errorf("Unsupported arrow type %d", f.byteField(2, 0))
+		}
+		if id := f.byteField(2, 0); id == arrowTypeLargeUtf8 || id == arrowTypeLargeBinary {
+			field.largeOffsets = true
+		}
+		rec.Fields = append(rec.Fields, field)
+	}
+	if err := schema.err(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// arrowReadBatch decodes a RecordBatch table and its body and appends its rows to rec
+func arrowReadBatch(rec *ArrowRecord, batch fbTable, body []byte) error {
+	if _, ok := batch.table(3); ok {
+		return errors.New("Compressed arrow bodies are not supported")
+	}
+	if batch.int64Field(0, 0) < 0 || batch.int64Field(0, 0) > int64(math.MaxInt32-rec.Length) {
+		return errors.New("Invalid record batch length")
+	}
+	length := int(batch.int64Field(0, 0))
+	if batch.vectorLen(1) != len(rec.Fields) {
+		if err := batch.err(); err != nil {
+			return err
+		}
+		return errors.New("Record batch does not match schema")
+	}
+	var next int
+	buffer := func() ([]byte, error) {
+		if next >= batch.vectorLen(2) {
+			return nil, errors.New("Record batch has too few buffers")
+		}
+		b := batch.structAt(2, next, 16)
+		next++
+		offset, size := b.int64At(0), b.int64At(8)
+		if offset < 0 || size < 0 || offset > int64(len(body)) || size > int64(len(body))-offset {
+			return nil, errors.New("Buffer extends past the message body")
+		}
+		return body[offset : offset+size], nil
+	}
+
+	if rec.Columns == nil {
+		rec.Columns = make([]ArrowColumn, len(rec.Fields))
+	}
+	for i, field := range rec.Fields {
+		node := batch.structAt(1, i, 16)
+		if node.int64At(0) != int64(length) || node.int64At(8) < 0 || node.int64At(8) > int64(length) {
+			if err := batch.err(); err != nil {
+				return err
+			}
+			return errors.New("Invalid field node")
+		}
+		nullCount := int(node.int64At(8))
+		if field.Type == ArrowNull {
+			rec.Columns[i].NullCount += nullCount
+			continue
+		}
+		validity, err := buffer()
+		if err != nil {
+			return err
+		}
+		var offsets []int64
+		if field.Type == ArrowString || field.Type == ArrowBinary {
+			raw, err := buffer()
+			if err != nil {
+				return err
+			}
+			width := 4
+			if field.largeOffsets {
+				width = 8
+			}
+			if length > 0 && len(raw) < width*(length+1) {
+				return errors.New("Offsets buffer is too short")
+			}
+			for j := 0; j <= length && length > 0; j++ {
+				if width == 4 {
+					offsets = append(offsets, int64(int32(binary.LittleEndian.Uint32(raw[4*j:]))))
+				} else {
+					offsets = append(offsets, int64(binary.LittleEndian.Uint64(raw[8*j:])))
+				}
+			}
+		}
+		data, err := buffer()
+		if err != nil {
+			return err
+		}
+		if nullCount == 0 || len(validity) == 0 {
+			validity = nil
+		} else if len(validity) < (length+7)/8 {
+			return errors.New("Validity buffer is too short")
+		}
+		if (field.Type == ArrowBool && len(data) < (length+7)/8) || len(data) < arrowWidth(field.Type)*length {
+			return errors.New("Data buffer is too short")
+		}
+		rec.Columns[i] = appendArrowColumn(rec.Columns[i], rec.Length, field, ArrowColumn{nullCount, validity, offsets, data}, length)
+	}
+	rec.Length += length
+	return nil
+}
+
+// appendArrowColumn appends the values of a batch column to a column holding n values
+func appendArrowColumn(col ArrowColumn, n int, field ArrowField, batch ArrowColumn, length int) ArrowColumn {
+	if col.Validity != nil || batch.Validity != nil {
+		bitmap := make([]byte, (n+length+7)/8)
+		for i := 0; i < n; i++ {
+			if col.Validity == nil || col.Validity[i/8]>>(i%8)&1 == 1 {
+				bitmap[i/8] |= 1 << (i % 8)
+			}
+		}
+		for i := 0; i < length; i++ {
+			if batch.Validity == nil || (i/8 < len(batch.Validity) && batch.Validity[i/8]>>(i%8)&1 == 1) {
+				bitmap[(n+i)/8] |= 1 << ((n + i) % 8)
+			}
+		}
+		col.Validity = bitmap
+	}
+	col.NullCount += batch.NullCount
+
+	switch {
+	case field.Type == ArrowBool:
+		for i := 0; i < length && i/8 < len(batch.Data); i++ {
+			col.Data = appendBit(col.Data, n+i, batch.Data[i/8]>>(i%8)&1 == 1)
+		}
+	case batch.Offsets != nil || field.Type == ArrowString || field.Type == ArrowBinary:
+		if col.Offsets == nil {
+			col.Offsets = []int64{0}
+		}
+		base := int64(len(col.Data))
+		if len(batch.Offsets) > 0 {
+			start, end := batch.Offsets[0], batch.Offsets[len(batch.Offsets)-1]
+			if start >= 0 && start <= end && end <= int64(len(batch.Data)) {
+				col.Data = append(col.Data, batch.Data[start:end]...)
+			}
+			for _, offset := range batch.Offsets[1:] {
+				col.Offsets = append(col.Offsets, base+offset-start)
+			}
+		}
+	default:
+		size := arrowWidth(field.Type) * length
+		if size > len(batch.Data) {
+			size = len(batch.Data)
+		}
+		col.Data = append(col.Data, batch.Data[:size]...)
+	}
+	return col
+}
+
+// fbTable is a flatbuffer table addressed by field id. Reads are checked against the bounds of the buffer: one out of range returns the zero value or default of the field and records an error, which every table of the buffer shares and err returns
+type fbTable struct {
+	buf    []byte
+	pos    int
+	failed *error
+}
+
+var errArrowFlatbuffer = errors.New("Invalid arrow flatbuffer")
+
+// fbRoot returns the root table of a finished flatbuffer
+func fbRoot(buf []byte) fbTable {
+	t := fbTable{buf: buf, failed: new(error)}
+	t.pos = t.uoffset(0)
+	return t
+}
+
+// err returns the error of the first read out of the bounds of the buffer, if any
+func (t fbTable) err() error {
+	if t.failed == nil {
+		return nil
+	}
+	return *t.failed
+}
+
+// read returns size bytes of the buffer from pos, or nil if they are out of its bounds
+func (t fbTable) read(pos, size int) []byte {
+	if pos < 0 || size < 0 || pos > len(t.buf)-size {
+		t.fail()
+		return nil
+	}
+	return t.buf[pos : pos+size]
+}
+
+// fail records that the buffer is invalid
+func (t fbTable) fail() {
+	if t.failed != nil && *t.failed == nil {
+		*t.failed = errArrowFlatbuffer
+	}
+}
+
+// uoffset returns the position an unsigned offset stored at pos points to
+func (t fbTable) uoffset(pos int) int {
+	b := t.read(pos, 4)
+	if b == nil {
+		return 0
+	}
+	return pos + int(binary.LittleEndian.Uint32(b))
+}
+
+// offset returns the position of a field relative to the table, or 0 if it is not set
+func (t fbTable) offset(id int) int {
+	if t.buf == nil {
+		return 0
+	}
+	b := t.read(t.pos, 4)
+	if b == nil {
+		return 0
+	}
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(b)))
+	b = t.read(vtable, 2)
+	if b == nil {
+		return 0
+	}
+	slot := 4 + 2*id
+	if slot+2 > int(binary.LittleEndian.Uint16(b)) {
+		return 0
+	}
+	if b = t.read(vtable+slot, 2); b == nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(b))
+}
+
+// field returns the size bytes of a scalar field, or nil if it is not set or out of range
+func (t fbTable) field(id int, size int) []byte {
+	if o := t.offset(id); o != 0 {
+		return t.read(t.pos+o, size)
+	}
+	return nil
+}
+
+func (t fbTable) byteField(id int, def byte) byte {
+	if b := t.field(id, 1); b != nil {
+		return b[0]
+	}
+	return def
+}
+
+func (t fbTable) boolField(id int, def bool) bool {
+	if b := t.field(id, 1); b != nil {
+		return b[0] != 0
+	}
+	return def
+}
+
+func (t fbTable) int16Field(id int, def int16) int16 {
+	if b := t.field(id, 2); b != nil {
+		return int16(binary.LittleEndian.Uint16(b))
+	}
+	return def
+}
+
+func (t fbTable) int32Field(id int, def int32) int32 {
+	if b := t.field(id, 4); b != nil {
+		return int32(binary.LittleEndian.Uint32(b))
+	}
+	return def
+}
+
+func (t fbTable) int64Field(id int, def int64) int64 {
+	if b := t.field(id, 8); b != nil {
+		return int64(binary.LittleEndian.Uint64(b))
+	}
+	return def
+}
+
+func (t fbTable) stringField(id int) string {
+	start, n := t.vector(id, 1)
+	if b := t.read(start, n); n > 0 && b != nil {
+		return string(b)
+	}
+	return ""
+}
+
+func (t fbTable) table(id int) (fbTable, bool) {
+	o := t.offset(id)
+	if o == 0 {
+		return fbTable{}, false
+	}
+	return fbTable{buf: t.buf, pos: t.uoffset(t.pos + o), failed: t.failed}, true
+}
+
+// vector returns the position of the first element of a vector field and its length, checking that its elements of the given size are within the buffer
+func (t fbTable) vector(id int, size int) (int, int) {
+	o := t.offset(id)
+	if o == 0 {
+		return 0, 0
+	}
+	pos := t.uoffset(t.pos + o)
+	b := t.read(pos, 4)
+	if b == nil {
+		return 0, 0
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if t.read(pos+4, n*size) == nil {
+		return 0, 0
+	}
+	return pos + 4, n
+}
+
+func (t fbTable) vectorLen(id int) int {
+	_, n := t.vector(id, 4) // elements are offsets or structs of at least 4 bytes
+	return n
+}
+
+func (t fbTable) tableAt(id int, i int) fbTable {
+	start, n := t.vector(id, 4)
+	if i >= n {
+		t.fail()
+		return fbTable{failed: t.failed}
+	}
+	return fbTable{buf: t.buf, pos: t.uoffset(start + 4*i), failed: t.failed}
+}
+
+// structAt returns the bytes of struct i of a vector of structs of the given size
+func (t fbTable) structAt(id int, i int, size int) fbStruct {
+	start, n := t.vector(id, size)
+	if i >= n {
+		t.fail()
+		return nil
+	}
+	return fbStruct(t.read(start+size*i, size))
+}
+
+// fbStruct is the raw bytes of a flatbuffer struct
+type fbStruct []byte
+
+func (s fbStruct) int64At(off int) int64 {
+	if off+8 > len(s) {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(s[off:]))
+}
+
+// fbVectorOfTables adds a vector of table offsets to b
+func fbVectorOfTables(b *flatbuffers.Builder, tables []flatbuffers.UOffsetT) flatbuffers.UOffsetT {
+	b.StartVector(4, len(tables), 4)
+	for i := len(tables) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(tables[i])
+	}
+	return b.EndVector(len(tables))
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// Categorical is the dictionary of a categorical column. The cells of the column hold *Category values pointing into it, so each distinct value is stored once and cells compare and group by their integer codes. Categoricals are never modified once created: category operations build a new one
+type Categorical struct {
+	ordered    bool
+	categories []*Category
+	lookup     map[interface{}]*Category
+}
+
+// Category is a single category of a Categorical, as held by the cells of a categorical column
+type Category struct {
+	value       interface{}
+	code        int
+	categorical *Categorical
+}
+
+// Value returns the value the category stands for
+func (c *Category) Value() interface{} {
+	return c.value
+}
+
+// Code returns the position of the category in its Categorical
+func (c *Category) Code() int {
+	return c.code
+}
+
+// String formats the value of the category
+func (c *Category) String() string {
+	return DefaultFormats.Format(nil, c.value)
+}
+
+// Compare returns -1, 0 or 1 as c comes before, is equal to or comes after other in the order of the categories. Both must belong to the same ordered Categorical
+func (c *Category) Compare(other *Category) (int, error) {
+	if c.categorical != other.categorical {
+		return 0, errors.New("Categories belong to different categoricals")
+	}
+	if !c.categorical.ordered {
+		return 0, errors.New("Categorical is not ordered")
+	}
+	switch {
+	case c.code < other.code:
+		return -1, nil
+	case c.code > other.code:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// NewCategorical creates a Categorical from distinct category values, in order
+func NewCategorical(categories []interface{}, ordered bool) (*Categorical, error) {
+	c := &Categorical{ordered: ordered, lookup: make(map[interface{}]*Category, len(categories))}
+	for _, value := range categories {
+		if IsNA(value) {
+			return nil, errors.New("Categories cannot be missing")
+		}
+		if _, ok := c.lookup[value]; ok {
+			return nil, fmt.Errorf("Category %v is duplicated", value)
+		}
+		cat := &Category{value: value, code: len(c.categories), categorical: c}
+		c.categories = append(c.categories, cat)
+		c.lookup[value] = cat
+	}
+	return c, nil
+}
+
+// Categories returns the category values in order
+func (c *Categorical) Categories() []interface{} {
+	out := make([]interface{}, len(c.categories))
+	for i, cat := range c.categories {
+		out[i] = cat.value
+	}
+	return out
+}
+
+// Ordered reports whether the order of the categories is meaningful, ie. categories can be compared
+func (c *Categorical) Ordered() bool {
+	return c.ordered
+}
+
+// Get returns the category of a value, or nil if the value is not a category
+func (c *Categorical) Get(value interface{}) *Category {
+	return c.lookup[value]
+}
+
+// AsCategorical returns a copy of the table with a column (by name or position) converted to a categorical column. If categories is nil, the distinct non-missing values of the column are used in order of first appearance. Values which are not among the categories become nil
+func (t *Table) AsCategorical(column interface{}, categories []interface{}, ordered bool) (*Table, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		for _, val := range getuniqs(GetTranspose(t.Vals, pos)) {
+			if !IsNA(val) {
+				categories = append(categories, val)
+			}
+		}
+	}
+	c, err := NewCategorical(categories, ordered)
+	if err != nil {
+		return nil, err
+	}
+	return t.recode(pos, c, func(val interface{}) interface{} {
+		if cat, ok := val.(*Category); ok {
+			val = cat.value
+		}
+		if cat := c.Get(val); cat != nil && !IsNA(val) {
+			return cat
+		}
+		return nil
+	}), nil
+}
+
+// recode returns a copy of the table where the column at pos becomes a categorical column of c, with f applied to every cell
+func (t *Table) recode(pos int, c *Categorical, f func(interface{}) interface{}) *Table {
+	t0 := t.Copy(true)
+	for _, row := range t0.Vals {
+		row[pos] = f(row[pos])
+	}
+	if t0.categoricals == nil {
+		t0.categoricals = make(map[interface{}]*Categorical)
+	}
+	t0.categoricals[t.Header.Slice[pos]] = c
+	return t0
+}
+
+// Categorical returns the Categorical of a categorical column (by name or position). An error is returned if the column holds anything but categories of a single Categorical and missing values. A column holding only missing values keeps the Categorical it was given by AsCategorical or a category operation through copies, selections, renames, Reindex, Concat and Combine, but not once it is dropped, replaced, converted or transposed
+func (t *Table) Categorical(column interface{}) (*Categorical, error) {
+	c, _, err := t.categorical(column)
+	return c, err
+}
+
+// categorical is the same as Categorical but also returns the position of the column
+func (t *Table) categorical(column interface{}) (*Categorical, int, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return nil, -1, err
+	}
+	var c *Categorical
+	for _, row := range t.Vals {
+		if row[pos] == nil {
+			continue
+		}
+		cat, ok := row[pos].(*Category)
+		if !ok || (c != nil && cat.categorical != c) {
+			return nil, -1, fmt.Errorf("Column %v is not categorical", t.Header.Slice[pos])
+		}
+		c = cat.categorical
+	}
+	if c == nil {
+		c = t.categoricals[t.Header.Slice[pos]]
+	}
+	if c == nil {
+		return nil, -1, fmt.Errorf("Column %v is not categorical", t.Header.Slice[pos])
+	}
+	return c, pos, nil
+}
+
+// Codes returns the codes of a categorical column (by name or position), -1 for missing values
+func (t *Table) Codes(column interface{}) ([]int, error) {
+	_, pos, err := t.categorical(column)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]int, len(t.Vals))
+	for i, row := range t.Vals {
+		codes[i] = -1
+		if cat, ok := row[pos].(*Category); ok {
+			codes[i] = cat.code
+		}
+	}
+	return codes, nil
+}
+
+// withCategories returns a copy of the table where the categorical column at pos uses new categories. Each cell keeps the category value returned by mapValue, or becomes nil if it returns a value which is not a category
+func (t *Table) withCategories(pos int, categories []interface{}, ordered bool, mapValue func(interface{}) interface{}) (*Table, error) {
+	c, err := NewCategorical(categories, ordered)
+	if err != nil {
+		return nil, err
+	}
+	return t.recode(pos, c, func(val interface{}) interface{} {
+		cat, ok := val.(*Category)
+		if !ok {
+			return nil
+		}
+		if newCat := c.Get(mapValue(cat.value)); newCat != nil {
+			return newCat
+		}
+		return nil
+	}), nil
+}
+
+// AddCategories returns a copy of the table where the categorical column (by name or position) has the given categories appended to its categories
+func (t *Table) AddCategories(column interface{}, categories ...interface{}) (*Table, error) {
+	c, pos, err := t.categorical(column)
+	if err != nil {
+		return nil, err
+	}
+	return t.withCategories(pos, append(c.Categories(), categories...), c.ordered, func(val interface{}) interface{} {
+		return val
+	})
+}
+
+// RemoveCategories returns a copy of the table where the categorical column (by name or position) no longer has the given categories. Cells holding them become nil
+func (t *Table) RemoveCategories(column interface{}, categories ...interface{}) (*Table, error) {
+	c, pos, err := t.categorical(column)
+	if err != nil {
+		return nil, err
+	}
+	remove := make(map[interface{}]bool, len(categories))
+	for _, value := range categories {
+		if c.Get(value) == nil {
+			return nil, fmt.Errorf("%v is not a category", value)
+		}
+		remove[value] = true
+	}
+	var kept []interface{}
+	for _, value := range c.Categories() {
+		if !remove[value] {
+			kept = append(kept, value)
+		}
+	}
+	return t.withCategories(pos, kept, c.ordered, func(val interface{}) interface{} {
+		return val
+	})
+}
+
+// RenameCategories returns a copy of the table where categories of the categorical column (by name or position) are renamed by mapper. Codes and order are kept
+func (t *Table) RenameCategories(column interface{}, mapper map[interface{}]interface{}) (*Table, error) {
+	c, pos, err := t.categorical(column)
+	if err != nil {
+		return nil, err
+	}
+	rename := func(val interface{}) interface{} {
+		if newVal, ok := mapper[val]; ok {
+			return newVal
+		}
+		return val
+	}
+	renamed := c.Categories()
+	for i, value := range renamed {
+		renamed[i] = rename(value)
+	}
+	return t.withCategories(pos, renamed, c.ordered, rename)
+}
+
+// SortByCategory returns a copy of the table with rows sorted by the category order of a categorical column (by name or position). Missing values come last and ties keep their order
+func (t *Table) SortByCategory(column interface{}, ascending bool) (*Table, error) {
+	codes, err := t.Codes(column)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(codes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ca, cb := codes[order[a]], codes[order[b]]
+		if ca < 0 || cb < 0 {
+			return cb < 0 && ca >= 0
+		}
+		if ascending {
+			return ca < cb
+		}
+		return ca > cb
+	})
+	return t.SliceILoc(0, order...), nil
+}
+
+// GroupByCategory returns an iterator over the categories of a categorical column (by name or position) which appear in it, in category order, each with a table of its rows. Rows are bucketed by code in a single pass and rows with a missing value are left out
+func (t *Table) GroupByCategory(column interface{}) (iter.Seq2[interface{}, *Table], error) {
+	c, err := t.Categorical(column)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := t.Codes(column)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([][]int, len(c.categories))
+	for i, code := range codes {
+		if code >= 0 {
+			groups[code] = append(groups[code], i)
+		}
+	}
+	return func(yield func(interface{}, *Table) bool) {
+		for code, rows := range groups {
+			if len(rows) == 0 {
+				continue
+			}
+			if !yield(c.categories[code].value, t.SliceILoc(0, rows...)) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+)
+
+// labelPosition returns the position of a label which must appear exactly once in ms. Kind names the axis in errors
+func labelPosition(ms MappedSlice, kind string, label interface{}) (int, error) {
+	indices := ms.Map[label]
+	switch len(indices) {
+	case 0:
+		return -1, fmt.Errorf("%s label %v not found", kind, label)
+	case 1:
+		return indices[0], nil
+	}
+	return -1, fmt.Errorf("%s label %v is ambiguous, found at positions %v", kind, label, indices)
+}
+
+// cellPosition returns the row and column positions of a cell by its labels
+func (t *Table) cellPosition(rowLabel, colLabel interface{}) (int, int, error) {
+	i, err := labelPosition(t.Index, "Row", rowLabel)
+	if err != nil {
+		return -1, -1, err
+	}
+	j, err := labelPosition(t.Header, "Column", colLabel)
+	if err != nil {
+		return -1, -1, err
+	}
+	return i, j, nil
+}
+
+// checkPosition returns an error if (i, j) is outside the table
+func (t *Table) checkPosition(i, j int) error {
+	if i < 0 || i >= len(t.Vals) {
+		return fmt.Errorf("Row position %d out of range", i)
+	}
+	if j < 0 || j >= len(t.Vals[i]) {
+		return fmt.Errorf("Column position %d out of range", j)
+	}
+	return nil
+}
+
+// At returns the value of a single cell by its index and header labels. Labels are always matched as labels, never as positions. An error is returned if either label is missing or duplicated
+func (t *Table) At(rowLabel, colLabel interface{}) (interface{}, error) {
+	i, j, err := t.cellPosition(rowLabel, colLabel)
+	if err != nil {
+		return nil, err
+	}
+	return t.Vals[i][j], nil
+}
+
+// IAt returns the value of a single cell by its row and column positions
+func (t *Table) IAt(i, j int) (interface{}, error) {
+	if err := t.checkPosition(i, j); err != nil {
+		return nil, err
+	}
+	return t.Vals[i][j], nil
+}
+
+// Set changes the value of a single cell by its index and header labels. Unlike most methods it modifies the table in place. An error is returned if either label is missing or duplicated
+func (t *Table) Set(rowLabel, colLabel interface{}, value interface{}) error {
+	i, j, err := t.cellPosition(rowLabel, colLabel)
+	if err != nil {
+		return err
+	}
+	t.Vals[i][j] = value
+	return nil
+}
+
+// ISet changes the value of a single cell by its row and column positions. Unlike most methods it modifies the table in place
+func (t *Table) ISet(i, j int, value interface{}) error {
+	if err := t.checkPosition(i, j); err != nil {
+		return err
+	}
+	t.Vals[i][j] = value
+	return nil
+}
+
+// UpdateWhere returns a copy of the table where the given column (by name or position) is set to value in every row where mask is true. Mask must have one entry per row, eg. as returned by Duplicated
+func (t *Table) UpdateWhere(mask []bool, column interface{}, value interface{}) (*Table, error) {
+	if len(mask) != len(t.Vals) {
+		return nil, fmt.Errorf("Mask has %d entries for %d rows", len(mask), len(t.Vals))
+	}
+	j, err := t.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	t0 := t.Copy(true)
+	for i, set := range mask {
+		if set {
+			t0.Vals[i][j] = value
+		}
+	}
+	return t0, nil
+}
+
+// UpdateWhereInPlace is the same as UpdateWhere but modifies the table in place
+func (t *Table) UpdateWhereInPlace(mask []bool, column interface{}, value interface{}) error {
+	t0, err := t.UpdateWhere(mask, column, value)
+	if err != nil {
+		return err
+	}
+	*t = *t0
+	return nil
+}
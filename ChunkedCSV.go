@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// ChunkedCSVReader reads a csv file as a sequence of Tables of at most Size rows, so files larger than memory can be processed chunk by chunk. Every chunk shares the same Header MappedSlice, which must not be modified in place. Without an index column the numeric index continues across chunks
+type ChunkedCSVReader struct {
+	Size   int
+	Header MappedSlice
+
+	reader      *csv.Reader
+	index       bool
+	indexHeader interface{}
+	rows        int // rows read so far
+}
+
+// NewChunkedCSVReader creates a ChunkedCSVReader reading chunks of size rows from r. Header and index work the same as in FromCSVFile. The header line, if any, is read immediately
+func NewChunkedCSVReader(r io.Reader, size int, header bool, index bool) (*ChunkedCSVReader, error) {
+	if size < 1 {
+		return nil, errors.New("Chunk size must be positive")
+	}
+	cr := &ChunkedCSVReader{
+		Size:        size,
+		reader:      csv.NewReader(r),
+		index:       index,
+		indexHeader: "Index"}
+	cr.reader.ReuseRecord = false
+
+	if header {
+		record, err := cr.reader.Read()
+		if err == io.EOF {
+			return nil, errors.New("No header line")
+		} else if err != nil {
+			return nil, err
+		}
+		names := make([]interface{}, len(record))
+		for i, name := range record {
+			names[i] = name
+		}
+		if index {
+			cr.indexHeader = names[0]
+			names = names[1:]
+		}
+		cr.Header = CreateGenMS(1, names)
+	}
+	return cr, nil
+}
+
+// Next returns the next chunk of rows. It returns io.EOF once every row has been read
+func (cr *ChunkedCSVReader) Next() (*Table, error) {
+	var labels []interface{}
+	var vals [][]interface{}
+	for len(vals) < cr.Size {
+		record, err := cr.reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		row := make([]interface{}, len(record))
+		for i, cell := range record {
+			row[i] = cell
+		}
+		if cr.index {
+			labels = append(labels, row[0])
+			row = row[1:]
+		} else {
+			labels = append(labels, strconv.Itoa(cr.rows))
+		}
+		if cr.Header.Slice == nil { // no header line, so number the columns from the first row
+			cr.Header = CreateNumMS(1, len(row))
+		}
+		vals = append(vals, row)
+		cr.rows++
+	}
+	if len(vals) == 0 {
+		return nil, io.EOF
+	}
+
+	return &Table{
+		Header: cr.Header,
+		Index:  CreateMS(labels, cr.indexHeader),
+		Vals:   vals}, nil
+}
+
+// Chunks returns an iterator over the remaining chunks for use with range. Iteration stops after the first error, which is yielded with a nil Table
+func (cr *ChunkedCSVReader) Chunks() iter.Seq2[*Table, error] {
+	return func(yield func(*Table, error) bool) {
+		for {
+			t, err := cr.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(t, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+// cell returns the value at (i, j) or nil if either position is -1
+func (t *Table) cell(i, j int) interface{} {
+	if i < 0 || j < 0 {
+		return nil
+	}
+	return t.Vals[i][j]
+}
+
+// Combine returns a table holding the union of the rows and columns of both tables, aligned by index and header labels, where every cell is f(value in t, value in other). Cells missing from one of the tables are passed to f as nil. Labels of t come first, followed by those only found in other
+func (t *Table) Combine(other *Table, f func(a, b interface{}) interface{}) *Table {
+	rows, rowPos := alignLabels(t.Index, other.Index)
+	cols, colPos := alignLabels(t.Header, other.Header)
+	rowsA, rowsB, colsA, colsB := rowPos[0], rowPos[1], colPos[0], colPos[1]
+
+	vals := make([][]interface{}, len(rows))
+	for i := range rows {
+		vals[i] = make([]interface{}, len(cols))
+		for j := range cols {
+			vals[i][j] = f(t.cell(rowsA[i], colsA[j]), other.cell(rowsB[i], colsB[j]))
+		}
+	}
+	t0 := &Table{
+		Header: CreateMS(cols, t.Header.Header),
+		Index:  CreateMS(rows, t.Index.Header),
+		Vals:   vals}
+	t0.inheritCategoricals(t, other)
+	return t0
+}
+
+// CombineFirst returns a table holding the union of the rows and columns of both tables, taking values from t and filling its missing values from other
+func (t *Table) CombineFirst(other *Table) *Table {
+	return t.Combine(other, func(a, b interface{}) interface{} {
+		if IsNA(a) {
+			return b
+		}
+		return a
+	})
+}
+
+// Update returns a copy of the table where every cell is overwritten by the aligned non-missing value of other. Rows and columns only found in other are ignored
+func (t *Table) Update(other *Table) *Table {
+	_, rowPos := alignLabels(t.Index, other.Index)
+	_, colPos := alignLabels(t.Header, other.Header)
+	rowsA, rowsB, colsA, colsB := rowPos[0], rowPos[1], colPos[0], colPos[1]
+
+	t0 := t.Copy(true)
+	for i, row := range rowsA {
+		if row < 0 || rowsB[i] < 0 {
+			continue
+		}
+		for j, col := range colsA {
+			if col < 0 || colsB[j] < 0 {
+				continue
+			}
+			if val := other.Vals[rowsB[i]][colsB[j]]; !IsNA(val) {
+				t0.Vals[row][col] = val
+			}
+		}
+	}
+	return t0
+}
+
+// UpdateInPlace is the same as Update but modifies the table in place
+func (t *Table) UpdateInPlace(other *Table) {
+	*t = *t.Update(other)
+}
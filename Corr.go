@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// CorrMethod is the correlation coefficient computed by Corr and CorrWith
+type CorrMethod uint8
+
+const (
+	Pearson  CorrMethod = iota // linear correlation
+	Spearman                   // Pearson correlation of the ranks
+	Kendall                    // Kendall's tau-b rank correlation
+)
+
+// numericColumns returns the positions and float values of every column holding only numbers and missing values, with at least one number. Missing values are NaN
+func (t *Table) numericColumns() ([]int, [][]float64) {
+	var positions []int
+	var cols [][]float64
+	for j := range t.Header.Slice {
+		col := make([]float64, len(t.Vals))
+		numeric := false
+		for i, row := range t.Vals {
+			if IsNA(row[j]) {
+				col[i] = math.NaN()
+				continue
+			}
+			f, ok := toFloat(row[j])
+			if _, isBool := row[j].(bool); !ok || isBool {
+				numeric = false
+				break
+			}
+			col[i] = f
+			numeric = true
+		}
+		if numeric {
+			positions = append(positions, j)
+			cols = append(cols, col)
+		}
+	}
+	return positions, cols
+}
+
+// pairwise returns the values of x and y at the positions where neither is NaN
+func pairwise(x, y []float64) ([]float64, []float64) {
+	var px, py []float64
+	for i := range x {
+		if !math.IsNaN(x[i]) && !math.IsNaN(y[i]) {
+			px = append(px, x[i])
+			py = append(py, y[i])
+		}
+	}
+	return px, py
+}
+
+// squareTable builds a table indexed and headed by names where cell (i, j) is f(i, j)
+func squareTable(names []interface{}, f func(i, j int) float64) *Table {
+	vals := make([][]interface{}, len(names))
+	for i := range names {
+		vals[i] = make([]interface{}, len(names))
+		for j := range names {
+			vals[i][j] = f(i, j)
+		}
+	}
+	return &Table{
+		Header: CreateGenMS(1, names),
+		Index:  CreateGenMS(0, copy1D(names)),
+		Vals:   vals}
+}
+
+// Corr returns the correlation matrix of the numeric columns, indexed and headed by their names. Every pair uses the rows where both values are present and gives NaN if there are fewer than minPeriods (at least 2) such rows
+func (t *Table) Corr(method CorrMethod, minPeriods int) *Table {
+	positions, cols := t.numericColumns()
+	names := make([]interface{}, len(positions))
+	for k, j := range positions {
+		names[k] = t.Header.Slice[j]
+	}
+	return squareTable(names, func(i, j int) float64 {
+		return correlation(cols[i], cols[j], method, minPeriods)
+	})
+}
+
+// Cov returns the sample covariance matrix of the numeric columns, indexed and headed by their names. Every pair uses the rows where both values are present and gives NaN if there are fewer than 2 such rows
+func (t *Table) Cov() *Table {
+	positions, cols := t.numericColumns()
+	names := make([]interface{}, len(positions))
+	for k, j := range positions {
+		names[k] = t.Header.Slice[j]
+	}
+	return squareTable(names, func(i, j int) float64 {
+		x, y := pairwise(cols[i], cols[j])
+		return covariance(x, y)
+	})
+}
+
+// seriesLike is satisfied by every Series[T]
+type seriesLike interface {
+	seriesIndex() MappedSlice
+	Interface() []interface{}
+}
+
+func (s Series[T]) seriesIndex() MappedSlice {
+	return s.Index
+}
+
+// CorrWith returns the correlation of every numeric column with other, aligned by index labels. Other is either a *Table, in which case each column is compared with the numeric column of the same name in other, or a Series of numbers compared with every column. The result is indexed by column names. Pairs give NaN if there are fewer than minPeriods (at least 2) aligned rows where both values are present
+func (t *Table) CorrWith(other interface{}, method CorrMethod, minPeriods int) (Series[float64], error) {
+	var otherIndex MappedSlice
+	var otherCols map[interface{}][]float64 // nil key for a Series
+	switch o := other.(type) {
+	case *Table:
+		otherIndex = o.Index
+		otherCols = make(map[interface{}][]float64)
+		positions, cols := o.numericColumns()
+		for k, j := range positions {
+			if _, ok := otherCols[o.Header.Slice[j]]; !ok {
+				otherCols[o.Header.Slice[j]] = cols[k]
+			}
+		}
+	case seriesLike:
+		otherIndex = o.seriesIndex()
+		single := o.Interface()
+		col := make([]float64, len(single))
+		for i, val := range single {
+			col[i] = math.NaN()
+			if f, ok := toFloat(val); ok && !IsNA(val) {
+				col[i] = f
+			} else if !IsNA(val) {
+				return Series[float64]{}, fmt.Errorf("Series value %v is not a number", val)
+			}
+		}
+		otherCols = map[interface{}][]float64{nil: col}
+	default:
+		return Series[float64]{}, errors.New("Other must be a *Table or a Series")
+	}
+
+	_, rowPos := alignLabels(t.Index, otherIndex)
+	rows, otherRows := rowPos[0], rowPos[1]
+	align := func(col []float64, pos []int) []float64 {
+		out := make([]float64, len(pos))
+		for i, p := range pos {
+			out[i] = math.NaN()
+			if p >= 0 {
+				out[i] = col[p]
+			}
+		}
+		return out
+	}
+
+	positions, cols := t.numericColumns()
+	var names []interface{}
+	var values []float64
+	for k, j := range positions {
+		name := t.Header.Slice[j]
+		otherCol, ok := otherCols[nil]
+		if _, isTable := other.(*Table); isTable {
+			otherCol, ok = otherCols[name]
+		}
+		if !ok {
+			continue
+		}
+		names = append(names, name)
+		values = append(values, correlation(align(cols[k], rows), align(otherCol, otherRows), method, minPeriods))
+	}
+	return NewSeries(method.String(), values, names), nil
+}
+
+// String returns the name of the method
+func (m CorrMethod) String() string {
+	switch m {
+	case Pearson:
+		return "pearson"
+	case Spearman:
+		return "spearman"
+	case Kendall:
+		return "kendall"
+	}
+	return fmt.Sprintf("CorrMethod(%d)", uint8(m))
+}
+
+// correlation computes the correlation of the pairwise complete values of x and y
+func correlation(x, y []float64, method CorrMethod, minPeriods int) float64 {
+	x, y = pairwise(x, y)
+	if len(x) < 2 || len(x) < minPeriods {
+		return math.NaN()
+	}
+	switch method {
+	case Spearman:
+		return pearson(averageRanks(x), averageRanks(y))
+	case Kendall:
+		return kendall(x, y)
+	}
+	return pearson(x, y)
+}
+
+func mean(x []float64) float64 {
+	var sum float64
+	for _, v := range x {
+		sum += v
+	}
+	return sum / float64(len(x))
+}
+
+// covariance is the sample covariance of x and y
+func covariance(x, y []float64) float64 {
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	mx, my := mean(x), mean(y)
+	var sum float64
+	for i := range x {
+		sum += (x[i] - mx) * (y[i] - my)
+	}
+	return sum / float64(len(x)-1)
+}
+
+func pearson(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	r := sxy / math.Sqrt(sxx*syy)
+	return math.Max(-1, math.Min(1, r))
+}
+
+// kendall computes tau-b, which accounts for ties
+func kendall(x, y []float64) float64 {
+	var concordant, discordant, tiesX, tiesY float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[i]-x[j], y[i]-y[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tiesX++
+			case dy == 0:
+				tiesY++
+			case (dx > 0) == (dy > 0):
+				concordant++
+			default:
+				discordant++
+			}
+		}
+	}
+	denom := math.Sqrt((concordant + discordant + tiesX) * (concordant + discordant + tiesY))
+	if denom == 0 {
+		return math.NaN()
+	}
+	return (concordant - discordant) / denom
+}
+
+// averageRanks ranks values from 1, giving ties the average of their ranks
+func averageRanks(x []float64) []float64 {
+	order := make([]int, len(x))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return x[order[a]] < x[order[b]] })
+	return rankSorted(order, func(a, b int) bool { return x[a] == x[b] }, RankAverage)
+}
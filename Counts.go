@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// IsNA reports whether a value is missing. nil and float NaN values are missing
+func IsNA(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case float64:
+		return math.IsNaN(v)
+	case float32:
+		return math.IsNaN(float64(v))
+	}
+	return false
+}
+
+// Unique returns the distinct values of a column (by name or position) in order of first appearance
+func (t *Table) Unique(column interface{}) []interface{} {
+	return getuniqs(GetTranspose(t.Vals, t.colPosition(column)))
+}
+
+// NUnique returns the number of distinct non-missing values in each row (axis == 0) or each column (axis == 1), in label order
+func (t *Table) NUnique(axis _Axis) []int {
+	vals := getValsOrient(axis, t.Vals)
+	counts := make([]int, len(vals))
+	for i, slice := range vals {
+		for _, val := range getuniqs(slice) {
+			if !IsNA(val) {
+				counts[i]++
+			}
+		}
+	}
+	return counts
+}
+
+// ValueCounts returns a table indexed by the distinct values of a column (by name or position) with a single "Count" column. If normalize is true, the column is "Proportion" and holds float64 frequencies instead. If sorted is true, rows are ordered by descending count, otherwise by first appearance. Missing values are counted together under the first one found, unless dropna is true, in which case they are not counted
+func (t *Table) ValueCounts(column interface{}, normalize bool, sorted bool, dropna bool) *Table {
+	pos := t.colPosition(column)
+
+	counts := make(map[interface{}]int)
+	var uniqs []interface{}
+	var total int
+	for _, row := range t.Vals {
+		val := row[pos]
+		if dropna && IsNA(val) {
+			continue
+		}
+		key := hashKey(val)
+		if counts[key] == 0 {
+			uniqs = append(uniqs, val)
+		}
+		counts[key]++
+		total++
+	}
+	if sorted {
+		sort.SliceStable(uniqs, func(i, j int) bool {
+			return counts[hashKey(uniqs[i])] > counts[hashKey(uniqs[j])]
+		})
+	}
+
+	name := "Count"
+	if normalize {
+		name = "Proportion"
+	}
+	vals := make([][]interface{}, len(uniqs))
+	for i, val := range uniqs {
+		count := counts[hashKey(val)]
+		if normalize {
+			vals[i] = []interface{}{float64(count) / float64(total)}
+		} else {
+			vals[i] = []interface{}{count}
+		}
+	}
+
+	return &Table{
+		Header: CreateGenMS(1, []interface{}{name}),
+		Index:  CreateMS(uniqs, t.Header.Slice[pos]),
+		Vals:   vals}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DatetimeLayouts are the layouts tried in order by ToDatetime when no layout is given
+var DatetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+	"02-Jan-2006",
+	"02 Jan 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	"20060102",
+}
+
+// ToDatetime returns a copy of the table with a column (by name or position) of strings parsed as time.Time. If layout is empty, every value is parsed with the first of DatetimeLayouts which fits it. Values without a time zone are taken to be in loc, UTC if loc is nil. Missing values and empty strings become nil and time.Time values are kept. An error names the first value which could not be parsed
+func (t *Table) ToDatetime(column interface{}, layout string, loc *time.Location) (*Table, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	layouts := DatetimeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+
+	t0 := t.Copy(true)
+	last := 0 // values in a column usually share a layout, so the last one that fit is tried first
+	for i, row := range t0.Vals {
+		switch val := row[pos].(type) {
+		case time.Time:
+			continue
+		case string:
+			s := strings.TrimSpace(val)
+			if s == "" {
+				row[pos] = nil
+				continue
+			}
+			parsed, ok := parseDatetime(s, layouts, &last, loc)
+			if !ok {
+				return nil, fmt.Errorf("Row %v: cannot parse %q as a date", t.Index.Slice[i], val)
+			}
+			row[pos] = parsed
+		default:
+			if !IsNA(val) {
+				return nil, fmt.Errorf("Row %v: cannot parse %T as a date", t.Index.Slice[i], val)
+			}
+			row[pos] = nil
+		}
+	}
+	delete(t0.categoricals, t.Header.Slice[pos])
+	return t0, nil
+}
+
+// parseDatetime tries layouts starting from layouts[*last] and remembers the one which fit
+func parseDatetime(s string, layouts []string, last *int, loc *time.Location) (time.Time, bool) {
+	for k := range layouts {
+		i := (*last + k) % len(layouts)
+		if parsed, err := time.ParseInLocation(layouts[i], s, loc); err == nil {
+			*last = i
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// DtAccessor holds date and time methods for a column. Values which are not time.Time are treated as missing and stay nil in every result
+type DtAccessor struct {
+	name   interface{}
+	index  MappedSlice
+	values []interface{}
+}
+
+// Dt returns the date and time methods of the Series
+func (s Series[T]) Dt() DtAccessor {
+	return DtAccessor{name: s.Name, index: s.Index, values: s.Interface()}
+}
+
+// Dt returns the date and time methods of a column (by name or position)
+func (t *Table) Dt(column interface{}) (DtAccessor, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return DtAccessor{}, err
+	}
+	return DtAccessor{name: t.Header.Slice[pos], index: t.Index, values: GetTranspose(t.Vals, pos)}, nil
+}
+
+// apply builds a Series from f called on every time value
+func (da DtAccessor) apply(f func(time.Time) interface{}) Series[interface{}] {
+	values := make([]interface{}, len(da.values))
+	for i, val := range da.values {
+		if tm, ok := val.(time.Time); ok {
+			values[i] = f(tm)
+		}
+	}
+	return Series[interface{}]{Name: da.name, Index: da.index.Copy(), Values: values}
+}
+
+// Year returns the year of each value, as ints
+func (da DtAccessor) Year() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Year() })
+}
+
+// Month returns the month of each value, as ints from 1 to 12
+func (da DtAccessor) Month() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return int(tm.Month()) })
+}
+
+// Day returns the day of the month of each value, as ints
+func (da DtAccessor) Day() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Day() })
+}
+
+// Weekday returns the day of the week of each value, as time.Weekday
+func (da DtAccessor) Weekday() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Weekday() })
+}
+
+// Hour returns the hour of each value, as ints
+func (da DtAccessor) Hour() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Hour() })
+}
+
+// Minute returns the minute of each value, as ints
+func (da DtAccessor) Minute() Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Minute() })
+}
+
+// Truncate returns each value rounded down to a multiple of d since the zero time, as time.Time.Truncate does. Multiples of a day are counted in calendar days in the location of the value, so days start at midnight and weeks on Monday
+func (da DtAccessor) Truncate(d time.Duration) Series[interface{}] {
+	day := 24 * time.Hour
+	return da.apply(func(tm time.Time) interface{} {
+		if d < day || d%day != 0 {
+			return tm.Truncate(d)
+		}
+		year, month, mday := tm.Date()
+		days := time.Date(year, month, mday, 0, 0, 0, 0, time.UTC).Unix()/86400 + 719162 // days since January 1, year 1
+		return time.Date(year, month, mday-int(days%int64(d/day)), 0, 0, 0, 0, tm.Location())
+	})
+}
+
+// Format returns each value formatted with layout, as strings
+func (da DtAccessor) Format(layout string) Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.Format(layout) })
+}
+
+// In returns each value converted to the time zone loc
+func (da DtAccessor) In(loc *time.Location) Series[interface{}] {
+	return da.apply(func(tm time.Time) interface{} { return tm.In(loc) })
+}
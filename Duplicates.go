@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keep selects which of a set of duplicates is not marked as a duplicate
+type Keep uint8
+
+const (
+	KeepFirst Keep = iota // all but the first occurrence are duplicates
+	KeepLast              // all but the last occurrence are duplicates
+	KeepNone              // every occurrence is a duplicate
+)
+
+// IsUnique reports whether every label of the MappedSlice occurs only once
+func (ms MappedSlice) IsUnique() bool {
+	return len(ms.Map) == ms.Length
+}
+
+// HasDuplicates reports whether any label of the MappedSlice occurs more than once
+func (ms MappedSlice) HasDuplicates() bool {
+	return !ms.IsUnique()
+}
+
+// Duplicated returns a mask which is true for every label that repeats an earlier (or later, depending on keep) label
+func (ms MappedSlice) Duplicated(keep Keep) []bool {
+	mask := make([]bool, ms.Length)
+	for _, indices := range ms.Map {
+		if len(indices) < 2 {
+			continue
+		}
+		for i, index := range indices {
+			switch keep {
+			case KeepFirst:
+				mask[index] = i != 0
+			case KeepLast:
+				mask[index] = i != len(indices)-1
+			case KeepNone:
+				mask[index] = true
+			}
+		}
+	}
+	return mask
+}
+
+// Duplicated returns a mask which is true for every row whose values in the subset columns (by name or position) repeat another row. If subset is empty, all columns are compared
+func (t *Table) Duplicated(subset []interface{}, keep Keep) []bool {
+	cols := make([]int, len(subset))
+	for i, column := range subset {
+		cols[i] = t.colPosition(column)
+	}
+
+	keys := make([]interface{}, len(t.Vals))
+	for i, row := range t.Vals {
+		if len(cols) == 0 {
+			keys[i] = rowKey(row)
+			continue
+		}
+		vals := make([]interface{}, len(cols))
+		for j, col := range cols {
+			vals[j] = row[col]
+		}
+		keys[i] = rowKey(vals)
+	}
+
+	return CreateMS(keys, nil).Duplicated(keep)
+}
+
+// DropDuplicates returns a copy of the table without the rows marked by Duplicated
+func (t *Table) DropDuplicates(subset []interface{}, keep Keep) *Table {
+	mask := t.Duplicated(subset, keep)
+	indices := make([]int, 0, len(mask))
+	for i, dupe := range mask {
+		if !dupe {
+			indices = append(indices, i)
+		}
+	}
+	return t.SliceILoc(0, indices...)
+}
+
+// DropDuplicatesInPlace is the same as DropDuplicates but modifies the table in place
+func (t *Table) DropDuplicatesInPlace(subset []interface{}, keep Keep) {
+	*t = *t.DropDuplicates(subset, keep)
+}
+
+// rowKey returns a hashable key for a row of values. Values of different types never share a key ie. "1", 1 and int64(1), while missing values all share one as in hashKey
+func rowKey(row []interface{}) string {
+	var b strings.Builder
+	for _, val := range row {
+		if IsNA(val) {
+			b.WriteString("NA;")
+			continue
+		}
+		fmt.Fprintf(&b, "%T(%#v);", val, val)
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColSpec is the range of characters [Start, End) holding a column of a fixed width file. An End of 0 or less reads until the end of the line
+type ColSpec struct {
+	Start, End int
+}
+
+// FromFixedWidth creates a Table from fixed width text, one row per line with every cell trimmed of surrounding spaces. Lines holding only spaces are skipped. If colspecs is empty the columns are inferred as the runs of character positions which are not a space on at least one line, so values must not contain spaces. Header and index work the same as in FromSlice
+func FromFixedWidth(r io.Reader, colspecs []ColSpec, header bool, index bool) (*Table, error) {
+	lines, err := readLines(r, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(colspecs) == 0 {
+		colspecs = inferColSpecs(lines)
+	}
+	if len(colspecs) == 0 {
+		return nil, errors.New("No columns found")
+	}
+
+	data := make([][]string, len(lines))
+	for i, line := range lines {
+		runes := []rune(line)
+		data[i] = make([]string, len(colspecs))
+		for j, spec := range colspecs {
+			start, end := spec.Start, spec.End
+			if end <= 0 || end > len(runes) {
+				end = len(runes)
+			}
+			if start < 0 || start >= end {
+				continue
+			}
+			data[i][j] = strings.TrimSpace(string(runes[start:end]))
+		}
+	}
+	return FromSlice(string2D{&data}, header, index), nil
+}
+
+// inferColSpecs finds the columns of fixed width lines, splitting at positions which are a space on every line
+func inferColSpecs(lines []string) []ColSpec {
+	var used []bool
+	for _, line := range lines {
+		for i, c := range []rune(line) {
+			for len(used) <= i {
+				used = append(used, false)
+			}
+			if c != ' ' && c != '\t' {
+				used[i] = true
+			}
+		}
+	}
+
+	var specs []ColSpec
+	for i := 0; i < len(used); i++ {
+		if !used[i] {
+			continue
+		}
+		start := i
+		for i < len(used) && used[i] {
+			i++
+		}
+		specs = append(specs, ColSpec{start, i})
+	}
+	return specs
+}
+
+// FromDelimited creates a Table from text where every line is split into cells by sep, eg. regexp.MustCompile(`\t`) for TSV files or `\s+` for whitespace separated files. There is no quoting, so cells cannot contain sep. Empty lines are skipped, but a line of empty cells such as "\t" is a row. Every line must have the same number of cells. Header and index work the same as in FromSlice
+func FromDelimited(r io.Reader, sep *regexp.Regexp, header bool, index bool) (*Table, error) {
+	lines, err := readLines(r, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([][]string, len(lines))
+	for i, line := range lines {
+		data[i] = sep.Split(line, -1)
+		if len(data[i]) != len(data[0]) {
+			return nil, fmt.Errorf("Line %d has %d fields, expected %d", i+1, len(data[i]), len(data[0]))
+		}
+	}
+	return FromSlice(string2D{&data}, header, index), nil
+}
+
+// readLines reads the non-empty lines of r without their line endings, also skipping lines holding only spaces if blank is true
+func readLines(r io.Reader, blank bool) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line != "" && !(blank && strings.TrimSpace(line) == "") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("No lines to read")
+	}
+	return lines, nil
+}
+
+// ToFixedWidth writes the table as fixed width text, left aligning every column to its widest cell and separating columns with a space. Cells are formatted with DefaultFormats. Header and index choose whether the header and index are written, so the output can be read back by FromFixedWidth with the same arguments as long as no cell contains a space
+func (t *Table) ToFixedWidth(w io.Writer, header bool, index bool) error {
+	rows := DefaultFormats.formatRows(t.Header.Slice, t.Vals)
+	if header {
+		rows = append([][]string{DefaultFormats.formatSlice(nil, t.Header.Slice)}, rows...)
+	}
+	if index {
+		labels := DefaultFormats.formatSlice(t.Index.Header, t.Index.Slice)
+		if header {
+			labels = append([]string{DefaultFormats.Format(nil, t.Index.Header)}, labels...)
+		}
+		for i := range rows {
+			rows[i] = append([]string{labels[i]}, rows[i]...)
+		}
+	}
+
+	var widths []int
+	for _, row := range rows {
+		for j, cell := range row {
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		for j, cell := range row {
+			if j == len(row)-1 {
+				bw.WriteString(cell)
+				break
+			}
+			bw.WriteString(cell)
+			bw.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)+1))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formatter converts a cell value to the string used when printing or exporting a table
+type Formatter func(interface{}) string
+
+// FormatRegistry holds Formatters keyed by Go type and by column name. Column Formatters take precedence over type Formatters, which take precedence over the builtin defaults
+type FormatRegistry struct {
+	mu      sync.RWMutex
+	types   map[reflect.Type]Formatter
+	columns map[interface{}]Formatter
+}
+
+// DefaultFormats is the registry used by PrintTable, ConvertToString1D and ConvertToString2D
+var DefaultFormats = NewFormatRegistry()
+
+// NewFormatRegistry creates an empty FormatRegistry which formats values using the builtin defaults
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{
+		types:   make(map[reflect.Type]Formatter),
+		columns: make(map[interface{}]Formatter)}
+}
+
+// RegisterType sets the Formatter used for every value with the same type as sample
+func (fr *FormatRegistry) RegisterType(sample interface{}, f Formatter) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.types[reflect.TypeOf(sample)] = f
+}
+
+// RegisterColumn sets the Formatter used for every value in columns with the given name
+func (fr *FormatRegistry) RegisterColumn(name interface{}, f Formatter) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.columns[name] = f
+}
+
+// Format returns the string representation of a value found in the given column. Pass a nil column to format by type only
+func (fr *FormatRegistry) Format(column interface{}, val interface{}) string {
+	fr.mu.RLock()
+	f, ok := fr.columns[column]
+	if !ok && val != nil {
+		f, ok = fr.types[reflect.TypeOf(val)]
+	}
+	fr.mu.RUnlock()
+	if ok {
+		return f(val)
+	}
+	return defaultFormat(val)
+}
+
+// formatSlice formats a slice of values which all belong to the given column
+func (fr *FormatRegistry) formatSlice(column interface{}, slice []interface{}) []string {
+	out := make([]string, len(slice))
+	for i, val := range slice {
+		out[i] = fr.Format(column, val)
+	}
+	return out
+}
+
+// formatRows formats rows of values where each position belongs to the matching column of header
+func (fr *FormatRegistry) formatRows(header []interface{}, rows [][]interface{}) [][]string {
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		out[i] = make([]string, len(row))
+		for j, cell := range row {
+			var column interface{}
+			if j < len(header) {
+				column = header[j]
+			}
+			out[i][j] = fr.Format(column, cell)
+		}
+	}
+	return out
+}
+
+// defaultFormat formats builtin numeric types, bools, strings, times, errors and fmt.Stringers. Missing values are formatted as an empty string
+func defaultFormat(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return formatFloat(v, 64)
+	case float32:
+		return formatFloat(float64(v), 32)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	}
+
+	// named types ie. type Qty int
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(rv.Float(), rv.Type().Bits())
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(rv.Complex(), 'f', -1, rv.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	case reflect.String:
+		return rv.String()
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return ""
+		}
+	}
+	return fmt.Sprint(val)
+}
+
+// formatFloat formats a float with the fewest digits needed to represent it, without an exponent. NaN is missing and is formatted as an empty string
+func formatFloat(f float64, bitSize int) string {
+	if f != f {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
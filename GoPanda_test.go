@@ -1,13 +1,41 @@
 package main
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
 var file1 string = "Data/test.csv"
 var file2 string = "Data/test1.csv"
 
+// priceTable returns the table of prices shared by the tests of the file and database formats, indexed by Symbol
+func priceTable() *Table {
+	return FromRecords([]struct {
+		Symbol string `table:",index"`
+		Date   time.Time
+		Price  float64
+		Volume int
+		Flag   bool
+		Note   interface{}
+	}{
+		{"eff", time.Date(2015, 7, 9, 0, 0, 0, 0, time.UTC), 523.119995, 1839400, true, nil},
+		{"efe", time.Date(2015, 7, 8, 9, 30, 0, 0, time.UTC), 521.049988, 1264600, false, "note"},
+		{"ffs", time.Date(2015, 7, 7, 0, 0, 0, 0, time.UTC), 510.5, 1000, true, math.NaN()},
+	})
+}
+
 func TestCreate(t *testing.T) {
 	// BOTH
 	table1 := FromCSVFile(file1, true, true)
@@ -74,7 +102,7 @@ func TestCreate(t *testing.T) {
 
 func TestResetIndex(t *testing.T) {
 	table1 := FromCSVFile(file1, true, true)
-	table1.ResetIndex()
+	table1.ResetIndexInPlace()
 	table1.PrintTable()
 
 	// +-------+--------+-----+-------+
@@ -193,7 +221,7 @@ func TestGenSliceLoc(t *testing.T) {
 
 func TestSetIndex(t *testing.T) {
 	table1 := FromCSVFile(file1, true, true)
-	table1.SetIndex(0)
+	table1.SetIndexInPlace(0)
 	table1.PrintTable()
 
 	// +-----+--------+-------+
@@ -228,7 +256,7 @@ func TestFromMap(t *testing.T) {
 
 func TestAddSlice(t *testing.T) {
 	table1 := FromCSVFile(file1, true, true)
-	table1.AddSlice(Axis(0), "check", []interface{}{0, 1})
+	table1.AddSliceInPlace(Axis(0), "check", []interface{}{0, 1})
 	table1.PrintTable()
 
 	// +--------+-----+-------+
@@ -243,7 +271,7 @@ func TestAddSlice(t *testing.T) {
 	// | check  |   0 |     1 |
 	// +--------+-----+-------+
 
-	table1.AddSlice(1, "check", []interface{}{0, 1, 2, 3, 4, 5, 6})
+	table1.AddSliceInPlace(1, "check", []interface{}{0, 1, 2, 3, 4, 5, 6})
 	table1.PrintTable()
 }
 
@@ -252,6 +280,12 @@ func TestPairedSliceLoc(t *testing.T) {
 
 	fmt.Println(table1.PairedSliceLoc(Axis(0), "efe", "trer", "hello"))
 	// [efe efe trer hello] [[3 5.32] [2 1.32] [<nil> <nil>] [<nil> <nil>]]
+
+	_, rows := table1.PairedSliceLoc(Axis(0), "eff")
+	rows[0][0] = "changed"
+	if table1.Vals[0][0] != "1" {
+		t.Error("PairedSliceLoc should not share rows with the table")
+	}
 }
 
 func TestUniqs(t *testing.T) {
@@ -262,6 +296,9 @@ func TestUniqs(t *testing.T) {
 	testSlice = getuniqs(testSlice)
 	fmt.Println("Uniqs", testSlice)
 	// Uniqs [1]
+
+	fmt.Println("Uniqs", getuniqs([]interface{}{math.NaN(), math.NaN(), 1.0, nil}))
+	// Uniqs [NaN 1]
 }
 
 func TestConcat(t *testing.T) {
@@ -282,15 +319,18 @@ func TestConcat(t *testing.T) {
 	// | vin    |   9 |  1.23 |
 	// +--------+-----+-------+
 
-	tableOut := Concat(Axis(0), table1, table2)
+	tableOut, err := Concat([]*Table{table1, table2}, ConcatOptions{Axis: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
 	tableOut.PrintTable()
 
-	// --------+-----+-------+-----+-------+
+	// +--------+-----+-------+-----+-------+
 	// | STRING | INT | FLOAT | INT | FLOAT |
 	// +--------+-----+-------+-----+-------+
 	// | eff    |   1 |   4.2 |   2 |  34.3 |
 	// | efe    |   3 |  5.32 |   8 |   7.2 |
-	// | efe_1  |   2 |  1.32 |   2 |   6.2 |
+	// | efe    |   2 |  1.32 |   2 |   6.2 |
 	// | ffs    |  52 |   2.1 |   4 |  7.47 |
 	// | wg     |  34 |    .8 |   5 |   7.5 |
 	// | ret    |   4 |   9.6 |     |       |
@@ -298,7 +338,10 @@ func TestConcat(t *testing.T) {
 	// | vin    |     |       |   9 |  1.23 |
 	// +--------+-----+-------+-----+-------+
 
-	tableOut = Concat(Axis(1), table1, table2)
+	tableOut, err = Concat([]*Table{table1, table2}, ConcatOptions{Axis: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
 	tableOut.PrintTable()
 
 	// +--------+-----+-------+
@@ -319,6 +362,57 @@ func TestConcat(t *testing.T) {
 	// | vin    |   9 |  1.23 |
 	// +--------+-----+-------+
 
+	tableOut, err = Concat([]*Table{table1, table2}, ConcatOptions{Axis: 0, Join: JoinInner, Keys: []interface{}{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableOut.PrintTable()
+
+	// +--------+----------+------------+----------+------------+
+	// | STRING | (A, INT) | (A, FLOAT) | (B, INT) | (B, FLOAT) |
+	// +--------+----------+------------+----------+------------+
+	// | eff    |        1 |        4.2 |        2 |       34.3 |
+	// | efe    |        3 |       5.32 |        8 |        7.2 |
+	// | efe    |        2 |       1.32 |        2 |        6.2 |
+	// | ffs    |       52 |        2.1 |        4 |       7.47 |
+	// | wg     |       34 |         .8 |        5 |        7.5 |
+	// +--------+----------+------------+----------+------------+
+
+	if _, err := tableOut.At("eff", MultiLabel{"b", "Float"}); err != nil {
+		t.Error(err)
+	}
+	fmt.Println(tableOut.DropCol(MultiLabel{"a", "Int"}).Header.Slice)
+	// [(a, Float) (b, Int) (b, Float)]
+	test := tableOut.SetIndex(MultiLabel{"b", "Int"})
+	fmt.Println(test.Index.Header, test.Index.Slice, test.Header.Slice)
+	// (b, Int) [2 8 2 4 5] [String (a, Int) (a, Float) (b, Float)]
+
+	// tables without rows used to panic when transposed
+	empty := &Table{Header: CreateGenMS(1, []interface{}{"Extra"}), Index: CreateMS(nil, "String")}
+	tableOut, err = Concat([]*Table{table1, empty}, ConcatOptions{Axis: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(tableOut.Header.Slice, tableOut.Vals[0])
+	// [Int Float Extra] [1 4.2 <nil>]
+
+	// int labels used to panic when renaming duplicates
+	table3 := FromSlice(interface2D{&[][]interface{}{{1, "x"}, {1, "y"}, {2, "z"}}}, false, true)
+	tableOut, err = Concat([]*Table{table3, table3}, ConcatOptions{Axis: 1, IgnoreIndex: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(tableOut.Index.Slice, tableOut.Vals)
+	// [0 1 2 3 4 5] [[x] [y] [z] [x] [y] [z]]
+
+	// ints which are labels select by label, other ints by position
+	table4 := FromSlice(interface2D{&[][]interface{}{{10, 20}, {"x", "y"}}}, true, false)
+	fmt.Println(table4.DropCol(10).Header.Slice, table4.DropCol(1).Header.Slice, table4.SetIndex(20).Index.Slice)
+	// [20] [10] [y]
+
+	if _, err := Concat([]*Table{table3, table3}, ConcatOptions{Axis: 1, VerifyIntegrity: true}); err == nil {
+		t.Error("VerifyIntegrity accepted duplicate labels")
+	}
 }
 
 func TestToMap(t *testing.T) {
@@ -327,6 +421,13 @@ func TestToMap(t *testing.T) {
 	// map[wg:[34 .8] ret:[4 9.6] Columns:[Int Float] eff:[1 4.2] efe:[2 1.32] ffs:[52 2.1]]
 	fmt.Println(table1.ToMap(Axis(1)))
 	// map[Int:[1 3 2 52 34 4] Float:[4.2 5.32 1.32 2.1 .8 9.6] String:[eff efe efe ffs wg ret]]
+
+	m := table1.ToMap(Axis(0))
+	m["eff"].([]interface{})[0] = "changed"
+	m["Columns"].([]interface{})[0] = "changed"
+	if table1.Vals[0][0] != "1" || table1.Header.Slice[0] != "Int" {
+		t.Error("ToMap should not share rows or labels with the table")
+	}
 }
 
 func TestAxis(t *testing.T) {
@@ -343,6 +444,1401 @@ func TestToSlice(t *testing.T) {
 
 func TestGeneral(t *testing.T) {
 	table1 := FromCSVFile(file1, true, true)
-	table1.ResetIndex()
+	table1 = table1.ResetIndex()
 	table1.GenSliceLoc(Axis(0), 1)
 }
+
+func TestCopy(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	shallow := table1.Copy(false)
+	shallow.Vals[0][0] = "shared"
+	shallow.AddSliceInPlace(Axis(0), "check", []interface{}{0, 1})
+	if table1.Vals[0][0] != "shared" || table1.Index.Length != 6 {
+		t.Error("shallow copy should share rows but not labels")
+	}
+
+	deep := table1.Copy(true)
+	deep.Vals[0][0] = "1"
+	deep.Index.AddVal("check")
+	if table1.Vals[0][0] != "shared" || len(table1.Index.Map["check"]) != 0 {
+		t.Error("deep copy should not share rows or labels")
+	}
+}
+
+func TestImmutable(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	table1.ResetIndex()
+	table1.SetIndex("Int")
+	table1.DropCol("Int")
+	table1.AddSlice(Axis(0), "check", []interface{}{0, 1})
+	table1.ToSlice()
+	if table1.Index.Header != "String" || table1.Header.Length != 2 || table1.Index.Length != 6 {
+		t.Error("transforming methods should not modify the receiver")
+	}
+
+	if table1.Loc(nil, nil) == table1 || table1.ILoc(nil, nil) == table1 {
+		t.Error("Loc and ILoc should return a new table")
+	}
+
+	test := table1.SliceLoc(Axis(0), "eff")
+	test.Vals[0][0] = "2"
+	test.Header.AddVal("check")
+	if table1.Vals[0][0] != "1" || table1.Header.Length != 2 {
+		t.Error("SliceLoc should not share rows or labels with the receiver")
+	}
+
+	test = table1.Transpose()
+	test.Index.AddVal("check")
+	if table1.Header.Length != 2 {
+		t.Error("Transpose should not share labels with the receiver")
+	}
+
+	table1.SetIndex("Int").ResetIndex().DropCol("Int").PrintTable()
+
+	// +-------+--------+-------+
+	// | INDEX | STRING | FLOAT |
+	// +-------+--------+-------+
+	// |     0 | eff    |   4.2 |
+	// |     1 | efe    |  5.32 |
+	// |     2 | efe    |  1.32 |
+	// |     3 | ffs    |   2.1 |
+	// |     4 | wg     |    .8 |
+	// |     5 | ret    |   9.6 |
+	// +-------+--------+-------+
+}
+
+func TestRename(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	test, err := table1.Rename(Axis(1), map[interface{}]interface{}{"Int": "Integer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.PrintTable()
+
+	// +--------+---------+-------+
+	// | STRING | INTEGER | FLOAT |
+	// +--------+---------+-------+
+	// | eff    |       1 |   4.2 |
+	// | efe    |       3 |  5.32 |
+	// | efe    |       2 |  1.32 |
+	// | ffs    |      52 |   2.1 |
+	// | wg     |      34 |    .8 |
+	// | ret    |       4 |   9.6 |
+	// +--------+---------+-------+
+
+	if _, ok := test.Header.Map["Int"]; ok || test.Header.Map["Integer"][0] != 0 {
+		t.Error("Rename should keep Header.Map consistent")
+	}
+
+	table1.RenameInPlace(Axis(0), func(label interface{}) interface{} {
+		return label.(string) + "!"
+	})
+	fmt.Println(table1.Index.Slice, table1.Index.Map["efe!"])
+	// [eff! efe! efe! ffs! wg! ret!] [1 2]
+
+	test, err = table1.Rename(Axis(1), map[string]string{"Float": "Price"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(test.Header.Slice)
+	// [Int Price]
+
+	if _, err := table1.Rename(Axis(1), []string{"Price"}); err == nil {
+		t.Error("Rename accepted a slice as mapper")
+	}
+	if err := table1.RenameInPlace(Axis(1), "Price"); err == nil || table1.Header.Slice[1] != "Float" {
+		t.Error("RenameInPlace accepted a string as mapper or modified the table", err)
+	}
+}
+
+func TestReindex(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	test := table1.Reindex(Axis(0), []interface{}{"ret", "efe", "new"}, nil)
+	test.PrintTable()
+
+	// +--------+-----+-------+
+	// | STRING | INT | FLOAT |
+	// +--------+-----+-------+
+	// | ret    |   4 |   9.6 |
+	// | efe    |   3 |  5.32 |
+	// | new    |     |       |
+	// +--------+-----+-------+
+
+	test = table1.Reindex(Axis(1), []interface{}{"Float", "Zero"}, "0")
+	test.PrintTable()
+
+	// +--------+-------+------+
+	// | STRING | FLOAT | ZERO |
+	// +--------+-------+------+
+	// | eff    |   4.2 |    0 |
+	// | efe    |  5.32 |    0 |
+	// | efe    |  1.32 |    0 |
+	// | ffs    |   2.1 |    0 |
+	// | wg     |    .8 |    0 |
+	// | ret    |   9.6 |    0 |
+	// +--------+-------+------+
+}
+
+func TestInsertMoveCol(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	test := table1.InsertCol(1, "New", []interface{}{"a", "b", "c", "d", "e", "f"})
+	test.MoveColInPlace("Float", 0)
+	test.PrintTable()
+
+	// +--------+-------+-----+-----+
+	// | STRING | FLOAT | INT | NEW |
+	// +--------+-------+-----+-----+
+	// | eff    |   4.2 |   1 | a   |
+	// | efe    |  5.32 |   3 | b   |
+	// | efe    |  1.32 |   2 | c   |
+	// | ffs    |   2.1 |  52 | d   |
+	// | wg     |    .8 |  34 | e   |
+	// | ret    |   9.6 |   4 | f   |
+	// +--------+-------+-----+-----+
+
+	if test.Header.Map["New"][0] != 2 || table1.Header.Length != 2 {
+		t.Error("InsertCol and MoveCol should keep Header.Map consistent")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	fmt.Println(table1.Index.IsUnique(), table1.Header.IsUnique(), table1.Index.Duplicated(KeepLast))
+	// false true [false true false false false false]
+
+	table2 := FromCSVFile(file2, true, false)
+	table2.AddSliceInPlace(Axis(0), "7", []interface{}{"wg", "5", "7.5"})
+
+	fmt.Println(table2.Duplicated(nil, KeepFirst))
+	// [false false false false false false false true]
+	fmt.Println(table2.Duplicated([]interface{}{"Int"}, KeepNone))
+	// [true true true false true true false true]
+
+	test := table2.DropDuplicates([]interface{}{"String", 1}, KeepFirst)
+	test.PrintTable()
+
+	// +-------+--------+-----+-------+
+	// | INDEX | STRING | INT | FLOAT |
+	// +-------+--------+-----+-------+
+	// |     0 | eff    |   2 |  34.3 |
+	// |     1 | efe    |   8 |   7.2 |
+	// |     2 | efe    |   2 |   6.2 |
+	// |     3 | ffs    |   4 |  7.47 |
+	// |     4 | wg     |   5 |   7.5 |
+	// |     5 | gr     |   8 |  56.7 |
+	// |     6 | vin    |   9 |  1.23 |
+	// +-------+--------+-----+-------+
+
+	table3 := FromSlice(interface2D{&[][]interface{}{{"Val"}, {1}, {int64(1)}, {"1"}, {nil}, {math.NaN()}, {int64(1)}}}, true, false)
+	mask := table3.Duplicated(nil, KeepFirst)
+	fmt.Println(mask)
+	// [false false false false true true]
+	if mask[1] || !mask[4] {
+		t.Error("Duplicated should compare types and treat missing values as equal", mask)
+	}
+}
+
+func TestValueCounts(t *testing.T) {
+	table1 := FromCSVFile(file1, true, false)
+	table1.AddSliceInPlace(Axis(0), "6", []interface{}{nil, "4", "1.1"})
+
+	fmt.Println(table1.Unique("Int"), table1.NUnique(Axis(1)))
+	// [1 3 2 52 34 4] [5 6 7]
+
+	test := table1.ValueCounts("String", false, true, true)
+	test.PrintTable()
+
+	// +--------+-------+
+	// | STRING | COUNT |
+	// +--------+-------+
+	// | efe    |     2 |
+	// | eff    |     1 |
+	// | ffs    |     1 |
+	// | wg     |     1 |
+	// | ret    |     1 |
+	// +--------+-------+
+
+	test = table1.ValueCounts(0, true, false, false)
+	fmt.Println(test.Index.Slice[5], test.Vals[5])
+	// <nil> [0.14285714285714285]
+
+	table2 := FromSlice(interface2D{&[][]interface{}{{"Float"}, {math.NaN()}, {math.NaN()}, {1.0}}}, true, false)
+	fmt.Println(table2.Unique("Float"), table2.NUnique(Axis(1)))
+	// [NaN 1] [1]
+
+	test = table2.ValueCounts("Float", false, true, false)
+	fmt.Println(test.Index.Slice, test.Vals)
+	// [NaN 1] [[2] [1]]
+
+	test = table2.ValueCounts("Float", true, false, true)
+	fmt.Println(test.Index.Slice, test.Vals)
+	// [1] [[1]]
+}
+
+func TestApply(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	length := func(slice []interface{}) interface{} {
+		return len(slice)
+	}
+	fmt.Println(table1.Apply(Axis(0), length), table1.ApplyParallel(Axis(1), length, 0))
+	// [2 2 2 2 2 2] [6 6]
+
+	test := table1.ApplyMapParallel(func(cell interface{}) interface{} {
+		return cell.(string) + "!"
+	}, 4)
+	test.PrintTable()
+
+	// +--------+-----+-------+
+	// | STRING | INT | FLOAT |
+	// +--------+-----+-------+
+	// | eff    | 1!  | 4.2!  |
+	// | efe    | 3!  | 5.32! |
+	// | efe    | 2!  | 1.32! |
+	// | ffs    | 52! | 2.1!  |
+	// | wg     | 34! | .8!   |
+	// | ret    | 4!  | 9.6!  |
+	// +--------+-----+-------+
+
+	test = table1.Transform(Axis(1), func(col []interface{}) []interface{} {
+		for i, j := 0, len(col)-1; i < j; i, j = i+1, j-1 {
+			col[i], col[j] = col[j], col[i]
+		}
+		return col
+	})
+	test.PrintTable()
+
+	// +--------+-----+-------+
+	// | STRING | INT | FLOAT |
+	// +--------+-----+-------+
+	// | eff    |   4 |   9.6 |
+	// | efe    |  34 |    .8 |
+	// | efe    |  52 |   2.1 |
+	// | ffs    |   2 |  1.32 |
+	// | wg     |   3 |  5.32 |
+	// | ret    |   1 |   4.2 |
+	// +--------+-----+-------+
+
+	if table1.Vals[0][0] != "1" {
+		t.Error("Transform should not modify the receiver")
+	}
+}
+
+func TestSeries(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+
+	s, err := Col[string](table1, "Int")
+	if err != nil {
+		t.Error(err)
+	}
+	fmt.Println(s.Name, s.Get("efe"), s.Len())
+	// Int [3 2] 6
+
+	if _, err := Col[int](table1, "Int"); err == nil {
+		t.Error("Col should report cells of the wrong type")
+	}
+
+	lengths := MapSeries(s, func(val string) int { return len(val) })
+	lengths.ToTable().PrintTable()
+
+	// +--------+-----+
+	// | STRING | INT |
+	// +--------+-----+
+	// | eff    |   1 |
+	// | efe    |   1 |
+	// | efe    |   1 |
+	// | ffs    |   2 |
+	// | wg     |   2 |
+	// | ret    |   1 |
+	// +--------+-----+
+}
+
+func TestFromRecords(t *testing.T) {
+	type record struct {
+		Name  string `table:"String"`
+		Count int
+		skip  bool
+	}
+
+	test := FromRecords([]record{{"eff", 1, false}, {"efe", 3, true}})
+	test.PrintTable()
+
+	// +-------+--------+-------+
+	// | INDEX | STRING | COUNT |
+	// +-------+--------+-------+
+	// |     0 | eff    |     1 |
+	// |     1 | efe    |     3 |
+	// +-------+--------+-------+
+
+	counts, err := Col[int](test, "Count")
+	if err != nil || counts.Values[1] != 3 {
+		t.Error("Col should return typed values")
+	}
+}
+
+func TestStructs(t *testing.T) {
+	type trade struct {
+		Symbol string  `table:"String,index"`
+		Qty    int     `table:"Int"`
+		Price  float64 `table:"Float"`
+	}
+
+	var trades []trade
+	table1 := FromCSVFile(file1, true, true)
+	if err := table1.ToStructs(&trades); err != nil {
+		t.Error(err)
+	}
+	fmt.Println(trades)
+	// [{eff 1 4.2} {efe 3 5.32} {efe 2 1.32} {ffs 52 2.1} {wg 34 0.8} {ret 4 9.6}]
+
+	test, err := FromStructs(trades)
+	if err != nil {
+		t.Error(err)
+	}
+	fmt.Println(test.Index.Header, test.Index.Slice, test.Vals[4])
+	// String [eff efe efe ffs wg ret] [34 0.8]
+
+	type missing struct {
+		Qty   int `table:"Int"`
+		Other int
+	}
+	var bad []*missing
+	table1.AddSliceInPlace(Axis(0), "bad", []interface{}{"x", nil})
+	fmt.Println(table1.ToStructs(&bad), bad[0].Qty)
+	// No column found for field Other
+	// Row bad, column Int: strconv.ParseInt: parsing "x": invalid syntax 1
+
+	type sized struct {
+		Qty   int   `table:"Int"`
+		Small uint8 `table:"Float"`
+	}
+	var lossy []sized
+	table2 := FromSlice(interface2D{&[][]interface{}{
+		{"Int", "Float"},
+		{3.0, 2},
+		{3.7, 300},
+		{int64(1) << 60, -1}}}, true, false)
+	err = table2.ToStructs(&lossy)
+	fmt.Println(err, lossy)
+	// Row 1, column Int: 3.7 has a fractional part and cannot be converted to int
+	// Row 1, column Float: 300 overflows uint8
+	// Row 2, column Float: -1 overflows uint8 [{3 2} {0 0} {1152921504606846976 0}]
+
+	if err == nil || lossy[1].Qty != 0 {
+		t.Error("ToStructs truncated 3.7 into an int field")
+	}
+}
+
+type testStringer struct{}
+
+func (testStringer) String() string { return "stringer" }
+
+func TestFormat(t *testing.T) {
+	fmt.Println(ConvertToString1D([]interface{}{1.5, int64(-2), uint8(3), true, nil,
+		time.Date(2015, 7, 9, 0, 0, 0, 0, time.UTC), errors.New("err"), testStringer{}, time.Second}))
+	// [1.5 -2 3 true  2015-07-09 err stringer 1s]
+
+	fr := NewFormatRegistry()
+	fr.RegisterType(0.0, func(val interface{}) string {
+		return strconv.FormatFloat(val.(float64), 'f', 2, 64)
+	})
+	fr.RegisterColumn("Qty", func(val interface{}) string {
+		return fmt.Sprintf("%v units", val)
+	})
+	fmt.Println(fr.formatRows([]interface{}{"Price", "Qty"}, [][]interface{}{{4.2, 1}}))
+	// [[4.20 1 units]]
+
+	table1 := FromRecords([]struct {
+		Float float64
+		Bool  bool
+	}{{4.2, true}, {0.8, false}})
+	table1.PrintTable()
+
+	// +-------+-------+-------+
+	// | INDEX | FLOAT | BOOL  |
+	// +-------+-------+-------+
+	// |     0 |   4.2 | true  |
+	// |     1 |   0.8 | false |
+	// +-------+-------+-------+
+}
+
+func TestRender(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true).ILoc([]int{0, 4}, nil)
+
+	table1.Render(os.Stdout, RenderOptions{Format: RenderMarkdown, Align: map[interface{}]Align{"String": AlignCenter}})
+	// | String | Int | Float |
+	// | :----: | --: | ----: |
+	// |  eff   |   1 |   4.2 |
+	// |   wg   |  34 |    .8 |
+
+	table1.Render(os.Stdout, RenderOptions{Format: RenderPlain, HideIndex: true})
+	// Int  Float
+	//   1    4.2
+	//  34     .8
+
+	table1.Render(os.Stdout, RenderOptions{Format: RenderLaTeX, MaxWidth: 3})
+	// \begin{tabular}{lrr}
+	// \hline
+	// St… & Int & Fl… \\
+	// \hline
+	// eff & 1 & 4.2 \\
+	// wg & 34 & .8 \\
+	// \hline
+	// \end{tabular}
+
+	table2 := FromRecords([]struct{ Price float64 }{{4.256}, {1}})
+	var b strings.Builder
+	table2.Render(&b, RenderOptions{Format: RenderHTML, Precision: 2, HideIndex: true})
+	fmt.Print(b.String())
+	// <table>
+	//   <thead>
+	//     <tr>
+	//       <th style="text-align: right;">Price</th>
+	//     </tr>
+	//   </thead>
+	//   <tbody>
+	//     <tr>
+	//       <td style="text-align: right;">4.26</td>
+	//     </tr>
+	//     <tr>
+	//       <td style="text-align: right;">1.00</td>
+	//     </tr>
+	//   </tbody>
+	// </table>
+}
+
+func TestXLSX(t *testing.T) {
+	table1 := FromCSVFile(file1, true, true)
+	table2 := FromRecords([]struct {
+		Date  time.Time
+		Float float64
+		Int   int
+		Bool  bool
+	}{
+		{time.Date(2015, 7, 9, 0, 0, 0, 0, time.UTC), 4.2, 1, true},
+		{time.Date(2015, 7, 8, 13, 30, 0, 0, time.UTC), -0.5, 52, false},
+	})
+
+	path := t.TempDir() + "/test.xlsx"
+	if err := ToXLSX(path, map[string]*Table{"Strings": table1, "Typed": table2}); err != nil {
+		t.Fatal(err)
+	}
+
+	test, err := FromXLSX(path, "Typed", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.PrintTable()
+
+	// +-------+----------------------+-------+-----+-------+
+	// | INDEX |         DATE         | FLOAT | INT | BOOL  |
+	// +-------+----------------------+-------+-----+-------+
+	// |     0 | 2015-07-09           |   4.2 |   1 | true  |
+	// |     1 | 2015-07-08T13:30:00Z |  -0.5 |  52 | false |
+	// +-------+----------------------+-------+-----+-------+
+
+	if _, ok := test.Vals[1][0].(time.Time); !ok {
+		t.Error("date cells should be read as time.Time")
+	}
+
+	test, err = FromXLSX(path, "", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(test.ToSlice()[2])
+	// [efe 3 5.32]
+
+	table3 := FromSlice(interface2D{&[][]interface{}{{"Float"}, {math.Inf(1)}, {math.NaN()}, {float32(math.Inf(-1))}, {1.5}}}, true, false)
+	if err := ToXLSX(path, map[string]*Table{"Special": table3}); err != nil {
+		t.Fatal(err)
+	}
+	test, err = FromXLSX(path, "Special", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("%#v\n", GetTranspose(test.Vals, 0))
+	// []interface {}{"+Inf", interface {}(nil), "-Inf", 1.5}
+	if test.Vals[0][0] != "+Inf" || test.Vals[1][0] != nil || test.Vals[3][0] != 1.5 {
+		t.Error("infinite and NaN floats should be written as text and empty cells", test.Vals)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	table1 := priceTable().ILoc([]int{0, 1}, nil)
+	table1.InsertColInPlace(3, "Small", []interface{}{int32(1), int32(-2)})
+
+	path := t.TempDir() + "/test.parquet"
+	for _, codec := range []ParquetCodec{ParquetUncompressed, ParquetSnappy} {
+		if err := WriteParquet(path, table1, codec); err != nil {
+			t.Fatal(err)
+		}
+		test, err := ReadParquet(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(test.ToSlice()) != fmt.Sprint(table1.ToSlice()) {
+			t.Error("parquet round trip changed the table", test.ToSlice())
+		}
+	}
+	test, err := ReadParquet(path, []string{"Volume", "Note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.PrintTable()
+
+	// +--------+---------+------+
+	// | SYMBOL | VOLUME  | NOTE |
+	// +--------+---------+------+
+	// | eff    | 1839400 |      |
+	// | efe    | 1264600 | note |
+	// +--------+---------+------+
+
+	fmt.Printf("%T\n", test.Vals[0][0])
+	// int64
+
+	test, err = ReadParquet(path, []string{"Symbol", "Price"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(test.Index.Header, test.Index.Slice, test.Header.Slice)
+	// Symbol [eff efe] [Price]
+
+	// corrupt files return errors instead of panicking
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := t.TempDir() + "/corrupt.parquet"
+	for i := range data {
+		for _, b := range []byte{0x00, 0xff, data[i] ^ 0x80} {
+			damaged := append([]byte(nil), data...)
+			damaged[i] = b
+			if err := os.WriteFile(corrupt, damaged, 0644); err != nil {
+				t.Fatal(err)
+			}
+			ReadParquet(corrupt, nil)
+		}
+	}
+	fmt.Println(decodeHybrid([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1, 8))
+	// [] Invalid hybrid encoded data
+	fmt.Println((&thriftDecoder{buf: bytes.Repeat([]byte{0x1c}, 1000)}).readStruct())
+	// map[] Thrift data is nested too deeply
+
+	// bit packed 0 to 7 with a bit width of 3, followed by a run of five 4s
+	fmt.Println(decodeHybrid([]byte{0x03, 0x88, 0xc6, 0xfa, 0x0a, 0x04}, 3, 13))
+	// [0 1 2 3 4 5 6 7 4 4 4 4 4] <nil>
+}
+
+func TestArrow(t *testing.T) {
+	table1 := priceTable().ILoc([]int{0, 1}, nil)
+	table1.InsertColInPlace(3, "Small", []interface{}{int8(1), int8(-2)})
+	table1.InsertColInPlace(5, "Raw", []interface{}{[]byte{1, 2}, []byte(nil)})
+	table1.Header.Header = "FIELD"
+
+	var buf bytes.Buffer
+	if err := WriteArrowStream(&buf, table1); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := ReadArrowStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/test.arrow"
+	if err := WriteArrowFile(path, table1); err != nil {
+		t.Fatal(err)
+	}
+	file, err := ReadArrowFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []*Table{stream, file} {
+		if fmt.Sprint(test.ToSlice()) != fmt.Sprint(table1.ToSlice()) {
+			t.Error("arrow round trip changed the table", test.ToSlice())
+		}
+		if test.Index.Header != "Symbol" || test.Header.Header != "FIELD" {
+			t.Error("arrow round trip lost the header names", test.Index.Header, test.Header.Header)
+		}
+	}
+
+	rec, err := table1.ToArrowRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, field := range rec.Fields {
+		fmt.Println(field.Name, field.Type, field.Nullable, rec.Columns[i].NullCount)
+	}
+	// Symbol 12 false 0
+	// Date 14 false 0
+	// Price 11 false 0
+	// Volume 5 false 0
+	// Small 2 false 0
+	// Flag 1 false 0
+	// Raw 13 false 0
+	// Note 12 true 1
+
+	mixed := FromSlice(interface2D{&[][]interface{}{{"Val"}, {int32(1)}, {int64(2)}}}, true, false)
+	fmt.Println(mixed.ToArrowRecord())
+	// <nil> Column Val mixes int32 and int64
+
+	// corrupt streams and files return errors instead of panicking
+	buf.Reset()
+	if err := WriteArrowStream(&buf, table1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := t.TempDir() + "/corrupt.arrow"
+	for i := range data {
+		for _, b := range []byte{0x00, 0xff, data[i] ^ 0x80} {
+			damaged := append([]byte(nil), data...)
+			damaged[i] = b
+			if err := os.WriteFile(corrupt, damaged, 0644); err != nil {
+				t.Fatal(err)
+			}
+			ReadArrowFile(corrupt)
+		}
+	}
+	for i := range buf.Bytes() {
+		for _, b := range []byte{0x00, 0xff, buf.Bytes()[i] ^ 0x80} {
+			damaged := append([]byte(nil), buf.Bytes()...)
+			damaged[i] = b
+			ReadArrowStream(bytes.NewReader(damaged))
+		}
+	}
+	fmt.Println(ReadArrowStream(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 8, 0, 0, 0, 0xff, 0xff, 0xff, 0x7f, 0, 0, 0, 0})))
+	// <nil> Invalid arrow flatbuffer
+}
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // every connection gets its own in-memory database
+
+	table1 := priceTable()
+	if err := table1.ToSQL(db, SQLite, "prices", IfExistsFail, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := table1.ToSQL(db, SQLite, "prices", IfExistsFail, 2); err == nil {
+		t.Error("ToSQL did not fail on an existing table")
+	}
+	if err := table1.ToSQL(db, SQLite, "prices", IfExistsAppend, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	test, err := FromSQL(db, `SELECT * FROM prices WHERE Volume > ? ORDER BY Symbol`, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.PrintTable()
+
+	// +-------+--------+----------------------+------------+---------+-------+------+
+	// | INDEX | SYMBOL |         DATE         |   PRICE    | VOLUME  | FLAG  | NOTE |
+	// +-------+--------+----------------------+------------+---------+-------+------+
+	// |     0 | efe    | 2015-07-08T09:30:00Z | 521.049988 | 1264600 | false | note |
+	// |     1 | efe    | 2015-07-08T09:30:00Z | 521.049988 | 1264600 | false | note |
+	// |     2 | eff    | 2015-07-09           | 523.119995 | 1839400 | true  |      |
+	// |     3 | eff    | 2015-07-09           | 523.119995 | 1839400 | true  |      |
+	// +-------+--------+----------------------+------------+---------+-------+------+
+
+	if err := table1.ToSQL(db, SQLite, "prices", IfExistsReplace, 0); err != nil {
+		t.Fatal(err)
+	}
+	test, err = FromSQL(db, `SELECT * FROM prices`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test = test.SetIndex("Symbol").DropCol("Index") // SetIndex moves the sequential index into the columns
+	for i, row := range test.Vals {
+		for j, val := range row {
+			want := table1.Vals[i][j]
+			if IsNA(want) {
+				want = nil
+			}
+			if fmt.Sprint(val) != fmt.Sprint(want) {
+				t.Errorf("sql round trip changed %v to %v", want, val)
+			}
+		}
+	}
+
+	for _, dialect := range []SQLDialect{SQLite, Postgres, MySQL, SQLServer} {
+		fmt.Println(dialect.quote("Price"), dialect.placeholder(2), dialect.columnType(1.5), dialect.columnType([]byte{}))
+	}
+	// "Price" ? DOUBLE PRECISION BLOB
+	// "Price" $2 DOUBLE PRECISION BYTEA
+	// `Price` ? DOUBLE LONGBLOB
+	// "Price" @p2 FLOAT VARBINARY(MAX)
+
+	db.Close()
+	if err := table1.ToSQL(db, SQLite, "other", IfExistsFail, 0); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Error("ToSQL should return the error of the existence check", err)
+	}
+}
+
+func TestFixedWidth(t *testing.T) {
+	text := `STRING  INT FLOAT
+eff       1   4.2
+efe       3  5.32
+ffs      52   2.1
+`
+	table1, err := FromFixedWidth(strings.NewReader(text), nil, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table1.PrintTable()
+
+	// +--------+-----+-------+
+	// | STRING | INT | FLOAT |
+	// +--------+-----+-------+
+	// | eff    |   1 |   4.2 |
+	// | efe    |   3 |  5.32 |
+	// | ffs    |  52 |   2.1 |
+	// +--------+-----+-------+
+
+	table2, err := FromFixedWidth(strings.NewReader(text), []ColSpec{{0, 6}, {6, 11}, {11, 0}}, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(table2.ToSlice()) != fmt.Sprint(table1.ToSlice()) {
+		t.Error("colspecs and inferred widths disagree", table2.ToSlice())
+	}
+
+	var buf bytes.Buffer
+	if err := table1.ToFixedWidth(&buf, true, true); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Print(buf.String())
+	// STRING INT FLOAT
+	// eff    1   4.2
+	// efe    3   5.32
+	// ffs    52  2.1
+
+	table3, err := FromFixedWidth(&buf, nil, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(table3.ToSlice()) != fmt.Sprint(table1.ToSlice()) {
+		t.Error("fixed width round trip changed the table", table3.ToSlice())
+	}
+
+	table4, err := FromDelimited(strings.NewReader("a\tb\tc\n1\t\t3\n"), regexp.MustCompile(`\t`), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(table4.Header.Slice, table4.Vals)
+	// [a b c] [[1  3]]
+
+	table5, err := FromDelimited(strings.NewReader("a\tb\n1\t2\n\t\n\n3\t4\n"), regexp.MustCompile(`\t`), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("%q\n", table5.Vals)
+	// [["1" "2"] ["" ""] ["3" "4"]]
+
+	if _, err := FromDelimited(strings.NewReader("a | b\n1 | 2 | 3\n"), regexp.MustCompile(`\s*\|\s*`), true, false); err == nil {
+		t.Error("FromDelimited accepted a ragged line")
+	}
+}
+
+func TestChunkedCSV(t *testing.T) {
+	file, err := os.Open("Data/test.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := NewChunkedCSVReader(file, 4, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int
+	for chunk, err := range reader.Chunks() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk.PrintTable()
+		total += chunk.Index.Length
+	}
+	fmt.Println(total)
+
+	// +-------+--------+-----+-------+
+	// | INDEX | STRING | INT | FLOAT |
+	// +-------+--------+-----+-------+
+	// |     0 | eff    |   1 |   4.2 |
+	// |     1 | efe    |   3 |  5.32 |
+	// |     2 | efe    |   2 |  1.32 |
+	// |     3 | ffs    |  52 |   2.1 |
+	// +-------+--------+-----+-------+
+	// +-------+--------+-----+-------+
+	// | INDEX | STRING | INT | FLOAT |
+	// +-------+--------+-----+-------+
+	// |     4 | wg     |  34 |    .8 |
+	// |     5 | ret    |   4 |   9.6 |
+	// +-------+--------+-----+-------+
+	// 6
+
+	file.Seek(0, io.SeekStart)
+	reader, err = NewChunkedCSVReader(file, 5, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := reader.Next()
+	second, _ := reader.Next()
+	if _, err := reader.Next(); err != io.EOF {
+		t.Error("expected io.EOF after the last chunk, got", err)
+	}
+	if fmt.Sprint(append(first.Index.Slice, second.Index.Slice...)) != "[eff efe efe ffs wg ret]" || first.Index.Header != "String" {
+		t.Error("chunks have the wrong index", first.Index, second.Index)
+	}
+}
+
+func TestIter(t *testing.T) {
+	table1 := FromCSVFile("Data/test.csv", true, true)
+
+	for label, row := range table1.IterRows() {
+		val, err := row.Get("Int")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Print(label, ":", val, " ")
+		if label == "ffs" {
+			break
+		}
+	}
+	fmt.Println()
+	// eff:1 efe:3 efe:2 ffs:52
+
+	for _, row := range table1.IterRows() {
+		if _, err := row.Get("Missing"); err == nil {
+			t.Error("Row.Get found a missing column")
+		}
+		break
+	}
+
+	for name, col := range table1.IterCols() {
+		fmt.Println(name, col.Values)
+	}
+	// Int [1 3 2 52 34 4]
+	// Float [4.2 5.32 1.32 2.1 .8 9.6]
+
+	for tuple := range table1.Itertuples() {
+		fmt.Print(tuple)
+	}
+	fmt.Println()
+	// [eff 1 4.2][efe 3 5.32][efe 2 1.32][ffs 52 2.1][wg 34 .8][ret 4 9.6]
+}
+
+func TestCell(t *testing.T) {
+	table1 := FromCSVFile("Data/test.csv", true, true)
+
+	val, err := table1.At("ffs", "Int")
+	fmt.Println(val, err)
+	// 52 <nil>
+
+	_, err = table1.At("efe", "Int")
+	fmt.Println(err)
+	// Row label efe is ambiguous, found at positions [1 2]
+
+	_, err = table1.At("eff", "Missing")
+	fmt.Println(err)
+	// Column label Missing not found
+
+	val, err = table1.IAt(5, 1)
+	fmt.Println(val, err)
+	// 9.6 <nil>
+
+	if _, err := table1.IAt(6, 0); err == nil {
+		t.Error("IAt accepted a row out of range")
+	}
+
+	if err := table1.Set("wg", "Float", 0.9); err != nil {
+		t.Fatal(err)
+	}
+	if err := table1.ISet(0, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	table2, err := table1.UpdateWhere(table1.Index.Duplicated(KeepFirst), "Int", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table2.PrintTable()
+
+	// +--------+-----+-------+
+	// | STRING | INT | FLOAT |
+	// +--------+-----+-------+
+	// | eff    |   2 |   4.2 |
+	// | efe    |   3 |  5.32 |
+	// | efe    |     |  1.32 |
+	// | ffs    |  52 |   2.1 |
+	// | wg     |  34 |   0.9 |
+	// | ret    |   4 |   9.6 |
+	// +--------+-----+-------+
+
+	if table1.Vals[2][0] != "2" {
+		t.Error("UpdateWhere modified the receiver")
+	}
+	if err := table1.UpdateWhereInPlace([]bool{true}, "Int", nil); err == nil {
+		t.Error("UpdateWhere accepted a short mask")
+	}
+}
+
+func TestCombine(t *testing.T) {
+	table1 := FromSlice(interface2D{&[][]interface{}{
+		{"Symbol", "Price", "Volume"},
+		{"eff", 4.2, nil},
+		{"efe", nil, 300},
+		{"ffs", 2.1, 500}}}, true, true)
+	table2 := FromSlice(interface2D{&[][]interface{}{
+		{"Symbol", "Volume", "Note"},
+		{"efe", 310, "fixed"},
+		{"eff", 100, nil},
+		{"gr", 7, "new"}}}, true, true)
+
+	table1.Update(table2).PrintTable()
+
+	// +--------+-------+--------+
+	// | SYMBOL | PRICE | VOLUME |
+	// +--------+-------+--------+
+	// | eff    |   4.2 |    100 |
+	// | efe    |       |    310 |
+	// | ffs    |   2.1 |    500 |
+	// +--------+-------+--------+
+
+	table1.CombineFirst(table2).PrintTable()
+
+	// +--------+-------+--------+-------+
+	// | SYMBOL | PRICE | VOLUME | NOTE  |
+	// +--------+-------+--------+-------+
+	// | eff    |   4.2 |    100 |       |
+	// | efe    |       |    300 | fixed |
+	// | ffs    |   2.1 |    500 |       |
+	// | gr     |       |      7 | new   |
+	// +--------+-------+--------+-------+
+
+	sum := table1.Combine(table2, func(a, b interface{}) interface{} {
+		x, ok1 := a.(int)
+		y, ok2 := b.(int)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		return x + y
+	})
+	fmt.Println(sum.Header.Slice, sum.Vals)
+	// [Price Volume Note] [[<nil> <nil> <nil>] [<nil> 610 <nil>] [<nil> <nil> <nil>] [<nil> <nil> <nil>]]
+
+	if table1.Vals[0][1] != nil {
+		t.Error("Update modified the receiver")
+	}
+	table1.UpdateInPlace(table2)
+	if table1.Vals[0][1] != 100 {
+		t.Error("UpdateInPlace did not modify the receiver")
+	}
+}
+
+func TestCategorical(t *testing.T) {
+	table1 := FromCSVFile("Data/test.csv", true, false)
+
+	table2, err := table1.AsCategorical("String", []interface{}{"wg", "efe", "eff", "ffs"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := table2.Categorical("String")
+	codes, _ := table2.Codes("String")
+	fmt.Println(c.Categories(), codes)
+	// [wg efe eff ffs] [2 1 1 3 0 -1]
+
+	if _, ok := table1.Vals[0][0].(string); !ok {
+		t.Error("AsCategorical modified the receiver")
+	}
+	if cmp, err := c.Get("wg").Compare(c.Get("ffs")); cmp != -1 || err != nil {
+		t.Error("wg should come before ffs", cmp, err)
+	}
+
+	sorted, err := table2.SortByCategory("String", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted.PrintTable()
+
+	// +-------+--------+-----+-------+
+	// | INDEX | STRING | INT | FLOAT |
+	// +-------+--------+-----+-------+
+	// |     4 | wg     |  34 |    .8 |
+	// |     1 | efe    |   3 |  5.32 |
+	// |     2 | efe    |   2 |  1.32 |
+	// |     0 | eff    |   1 |   4.2 |
+	// |     3 | ffs    |  52 |   2.1 |
+	// |     5 |        |   4 |   9.6 |
+	// +-------+--------+-----+-------+
+
+	table3, err := table2.RenameCategories("String", map[interface{}]interface{}{"efe": "EFE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table3, err = table3.AddCategories("String", "ret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table3, err = table3.RemoveCategories("String", "wg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ = table3.Categorical("String")
+	codes, _ = table3.Codes("String")
+	fmt.Println(c.Categories(), codes, table3.Vals[1][0])
+	// [EFE eff ffs ret] [1 0 0 2 -1 -1] EFE
+
+	groups, err := table2.GroupByCategory("String")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value, group := range groups {
+		fmt.Print(value, ":", group.Index.Slice, " ")
+	}
+	fmt.Println()
+	// wg:[4] efe:[1 2] eff:[0] ffs:[3]
+
+	if _, err := table1.Categorical("String"); err == nil {
+		t.Error("Categorical accepted a string column")
+	}
+
+	// a column left without values keeps its categories
+	table4, err := table2.RemoveCategories("String", "wg", "efe", "eff", "ffs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table4, err = table4.AddCategories("String", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ = table4.Categorical("String")
+	codes, _ = table4.Codes("String")
+	fmt.Println(c.Categories(), codes)
+	// [new] [-1 -1 -1 -1 -1 -1]
+
+	table4 = FromSlice(interface2D{&[][]interface{}{{"Key", "Side", "Qty"}, {"a", nil, 1}, {"b", nil, 2}}}, true, true)
+	table4, err = table4.AsCategorical("Side", []interface{}{"buy", "sell"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := table4.DropCol("Qty").Categorical("Side"); err != nil || len(c.Categories()) != 2 {
+		t.Error("Categorical lost the categories of a column without values", err)
+	}
+	table5, _ := Concat([]*Table{table4, table4.Reindex(0, []interface{}{"a", "z"}, nil)}, ConcatOptions{})
+	renamed, _ := table4.Rename(1, map[string]string{"Side": "Way"})
+	for _, test := range []*Table{table4.Copy(false), table4.ILoc([]int{1}, nil), table4.Reindex(1, []interface{}{"Side"}, nil), table5, table4.CombineFirst(table4), renamed} {
+		if _, err := test.Categorical(test.Header.Slice[0]); err != nil {
+			t.Error("Categorical lost the categories of a column without values", err)
+		}
+	}
+	replaced := table4.DropCol("Side").InsertCol(0, "Side", []interface{}{nil, nil})
+	kinds := map[interface{}]Kind{"Side": KindString}
+	converted, _ := table4.AsType(kinds, false)
+	for _, test := range []*Table{replaced, converted, renamed.InsertCol(0, "Side", []interface{}{nil, nil}), table4.Transpose().Transpose()} {
+		_, err := test.Categorical("Side")
+		fmt.Println(err)
+	}
+	// Column Side is not categorical
+	// Column Side is not categorical
+	// Column Side is not categorical
+	// Column Side is not categorical
+}
+
+func TestStr(t *testing.T) {
+	table1 := FromSlice(interface2D{&[][]interface{}{
+		{"Name", "Ticker"},
+		{"a", "  Alphabet Inc. (GOOG) "},
+		{"b", "apple inc (AAPL)"},
+		{"c", nil},
+		{"d", 42}}}, true, true)
+
+	str, err := table1.Str("Ticker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean := str.Strip().Str()
+	fmt.Println(clean.Lower().Values)
+	// [alphabet inc. (goog) apple inc (aapl) <nil> <nil>]
+	fmt.Println(clean.Upper().Str().HasPrefix("APPLE").Values, clean.Contains("Inc").Values)
+	// [false true <nil> <nil>] [true false <nil> <nil>]
+	fmt.Println(clean.Len().Values, clean.Replace("Inc.", "Inc", -1).Values)
+	// [20 16 <nil> <nil>] [Alphabet Inc (GOOG) apple inc (AAPL) <nil> <nil>]
+	fmt.Println(clean.ReplaceRegexp(regexp.MustCompile(`\s*\(.*\)`), "").Str().Pad(10, PadLeft, '.').Values)
+	// [Alphabet Inc. .apple inc <nil> <nil>]
+
+	clean.Split(" ", 2).PrintTable()
+
+	// +------+----------+-------------+
+	// | NAME |    0     |      1      |
+	// +------+----------+-------------+
+	// | a    | Alphabet | Inc. (GOOG) |
+	// | b    | apple    | inc (AAPL)  |
+	// | c    |          |             |
+	// | d    |          |             |
+	// +------+----------+-------------+
+
+	clean.Extract(regexp.MustCompile(`^(?P<Company>[^(]*?) \((?P<Symbol>\w+)\)$`)).PrintTable()
+
+	// +------+---------------+--------+
+	// | NAME |    COMPANY    | SYMBOL |
+	// +------+---------------+--------+
+	// | a    | Alphabet Inc. | GOOG   |
+	// | b    | apple inc     | AAPL   |
+	// | c    |               |        |
+	// | d    |               |        |
+	// +------+---------------+--------+
+}
+
+func TestDatetime(t *testing.T) {
+	table1 := FromCSVFile("table.csv", true, false).ILoc([]int{0, 1, 5}, []int{0, 1})
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	table2, err := table1.ToDatetime("Date", "", newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt, err := table2.Dt("Date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(dt.Year().Values, dt.Month().Values, dt.Day().Values, dt.Weekday().Values)
+	// [2015 2015 2015] [7 7 7] [9 8 1] [Thursday Wednesday Wednesday]
+	fmt.Println(dt.In(time.UTC).Dt().Format(time.RFC3339).Values, dt.In(time.UTC).Dt().Hour().Values)
+	// [2015-07-09T04:00:00Z 2015-07-08T04:00:00Z 2015-07-01T04:00:00Z] [4 4 4]
+	fmt.Println(dt.Truncate(7 * 24 * time.Hour).Dt().Format("Mon 2006-01-02").Values)
+	// [Mon 2015-07-06 Mon 2015-07-06 Mon 2015-06-29]
+
+	mixed := FromSlice(interface2D{&[][]interface{}{{"07/09/2015 13:45:00"}, {""}, {nil}, {"2015-07-09T13:45:00+02:00"}}}, false, false)
+	mixed, err = mixed.ToDatetime(0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(mixed.Vals)
+	// [[2015-07-09 13:45:00 +0000 UTC] [<nil>] [<nil>] [2015-07-09 13:45:00 +0200 +0200]]
+
+	if _, err := table1.ToDatetime("Date", "01/02/2006", nil); err == nil {
+		t.Error("ToDatetime accepted a value which does not fit the layout")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	table1 := FromSlice(interface2D{&[][]interface{}{
+		{"Symbol", "Date", "Volume", "Close", "Side"},
+		{"eff", "2015-07-09", "1839400", "520.68", "buy"},
+		{"efe", "07/08/2015", "12e3", "516.83", "sell"},
+		{"ffs", "", "-5", "x", "hold"}}}, true, true)
+
+	kinds := map[interface{}]Kind{"Date": KindTime, "Volume": KindInt, "Close": KindFloat}
+	_, err := table1.AsType(kinds, true)
+	fmt.Println(err)
+	// Row efe, column Volume: strconv.ParseInt: parsing "12e3": invalid syntax
+	// Row ffs, column Close: strconv.ParseFloat: parsing "x": invalid syntax
+
+	table2, err := table1.AsType(kinds, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(table2.Vals)
+	// [[2015-07-09 00:00:00 +0000 UTC 1839400 520.68 buy] [2015-07-08 00:00:00 +0000 UTC <nil> 516.83 sell] [<nil> -5 <nil> hold]]
+
+	table3 := FromSlice(interface2D{&[][]interface{}{{"Qty"}, {3.0}, {3.7}, {1e300}}}, true, false)
+	_, err = table3.AsType(map[interface{}]Kind{"Qty": KindInt}, true)
+	fmt.Println(err)
+	// Row 1, column Qty: 3.7 has a fractional part and cannot be converted to int
+	// Row 2, column Qty: 1e+300 overflows int
+	if err == nil {
+		t.Error("AsType truncated 3.7 to an int in strict mode")
+	}
+
+	table3, err = table3.AsType(map[interface{}]Kind{"Qty": KindInt}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(GetTranspose(table3.Vals, 0))
+	// [3 <nil> <nil>]
+
+	schema := Schema{Columns: []ColumnSchema{
+		{Name: "Date", Kind: KindTime, Min: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "Volume", Kind: KindInt, Nullable: true, Min: 0},
+		{Name: "Close", Kind: KindFloat, Nullable: true, Max: 1000},
+		{Name: "Side", Kind: KindString, Allowed: []interface{}{"buy", "sell"}},
+		{Name: "Open", Kind: KindFloat}},
+		Strict: true}
+	for _, violation := range table2.Validate(schema) {
+		fmt.Println(violation)
+	}
+	// Row ffs, column Date: missing value
+	// Row ffs, column Volume: below minimum 0
+	// Row ffs, column Side: value not allowed
+	// Column Open: missing column
+
+	if violations := table1.Validate(Schema{Columns: []ColumnSchema{{Name: "Volume", Kind: KindInt}}}); len(violations) != 3 {
+		t.Error("strings should not pass as ints", violations)
+	}
+}
+
+func TestCorr(t *testing.T) {
+	kinds := map[interface{}]Kind{"Open": KindFloat, "Close": KindFloat, "Volume": KindInt}
+	table1, err := FromCSVFile("table.csv", true, true).ILoc([]int{0, 1, 2, 3, 4, 5, 6, 7}, []int{0, 3, 4}).AsType(kinds, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table1.Vals[2][2] = nil
+
+	rounded := func(table *Table) [][]interface{} {
+		return table.ApplyMap(func(val interface{}) interface{} {
+			return math.Round(val.(float64)*1000) / 1000
+		}).Vals
+	}
+	fmt.Println(table1.Corr(Pearson, 1).Header.Slice, rounded(table1.Corr(Pearson, 1)))
+	// [Open Close Volume] [[1 -0.033 0.958] [-0.033 1 -0.006] [0.958 -0.006 1]]
+	fmt.Println(rounded(table1.Corr(Spearman, 1)))
+	// [[1 -0.167 0.821] [-0.167 1 -0.321] [0.821 -0.321 1]]
+	fmt.Println(rounded(table1.Corr(Kendall, 1)))
+	// [[1 -0.143 0.619] [-0.143 1 -0.238] [0.619 -0.238 1]]
+	fmt.Println(rounded(table1.Cov()))
+	// [[5.13 -0.182 951327.882] [-0.182 5.91 -5514.409] [951327.882 -5514.409 1.65000023333333e+11]]
+
+	if !math.IsNaN(table1.Corr(Pearson, 8).Vals[0][2].(float64)) {
+		t.Error("minPeriods did not apply to the pair with a missing value")
+	}
+
+	volume, err := Col[int](table1.ILoc([]int{0, 1, 3, 4, 5, 6, 7}, nil), "Volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	corr, err := table1.CorrWith(volume, Pearson, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(corr.Name, corr.Index.Slice, MapSeries(corr, func(f float64) float64 { return math.Round(f*1000) / 1000 }).Values)
+	// pearson [Open Close Volume] [0.958 -0.006 1]
+
+	renamed, err := table1.Rename(0, func(label interface{}) interface{} { return label })
+	if err != nil {
+		t.Fatal(err)
+	}
+	corr, err = table1.CorrWith(renamed, Spearman, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(corr.Values)
+	// [1 1 1]
+}
+
+func TestRank(t *testing.T) {
+	table1 := FromSlice(interface2D{&[][]interface{}{
+		{"Num", "Str"},
+		{3, "b"},
+		{1, "a"},
+		{nil, "c"},
+		{3, "a"},
+		{2, nil},
+		{3, "b"}}}, true, false)
+	for _, method := range []RankMethod{RankAverage, RankMin, RankMax, RankFirst, RankDense} {
+		ranks, err := table1.Rank("Num", method, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Println(method, ranks.Values)
+	}
+	// average [4 1 NaN 4 2 4]
+	// min [3 1 NaN 3 2 3]
+	// max [5 1 NaN 5 2 5]
+	// first [3 1 NaN 4 2 5]
+	// dense [3 1 NaN 3 2 3]
+
+	ranks, err := table1.Rank("Num", RankDense, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(ranks.Values)
+	// [0.3333333333333333 1 NaN 0.3333333333333333 0.6666666666666666 0.3333333333333333]
+
+	ranks, err = table1.Rank(1, RankMin, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(ranks.Name, ranks.Values)
+	// Str [0.6 0.2 1 0.2 NaN 0.6]
+
+	cut, err := table1.Cut("Num", []float64{1, 2, 3}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut.PrintTable()
+
+	// +-------+--------+-----+
+	// | INDEX |  NUM   | STR |
+	// +-------+--------+-----+
+	// |     0 | (2, 3] | b   |
+	// |     1 | [1, 2] | a   |
+	// |     2 |        | c   |
+	// |     3 | (2, 3] | a   |
+	// |     4 | [1, 2] |     |
+	// |     5 | (2, 3] | b   |
+	// +-------+--------+-----+
+
+	cut, err = table1.Cut("Num", []float64{1.5, 2.5, 3.5}, []interface{}{"low", "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(GetTranspose(cut.Vals, 0))
+	// [high <nil> <nil> high low high]
+
+	if _, err := table1.Cut("Str", []float64{1, 2}, nil); err == nil {
+		t.Error("Cut of a string column did not fail")
+	}
+	if _, err := table1.Cut("Num", []float64{1, 2}, []interface{}{"a", "b"}); err == nil {
+		t.Error("Cut with the wrong number of labels did not fail")
+	}
+
+	table2, err := FromCSVFile("table.csv", true, true).AsType(map[interface{}]Kind{"Volume": KindInt}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table2.Vals[0][4] = nil // missing values are left out of the deciles
+	deciles, err := table2.QCut("Volume", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := deciles.Categorical("Volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(c.Categories())
+	// [[7900, 1112080] (1112080, 1282800] (1282800, 1405340] (1405340, 1534960] (1534960, 1659100] (1659100, 1789740] (1789740, 1963180] (1963180, 2236420] (2236420, 2795480] (2795480, 6809500]]
+
+	groups, err := deciles.GroupByCategory("Volume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for label, group := range groups {
+		fmt.Println(label, len(group.Vals))
+	}
+	// [7900, 1112080] 33
+	// (1112080, 1282800] 32
+	// ...
+	// (2795480, 6809500] 33
+
+	if deciles.Vals[0][4] != nil {
+		t.Error("QCut did not keep the missing value")
+	}
+	if _, err := table1.QCut("Num", 4, nil); err == nil {
+		t.Error("QCut with equal edges did not fail")
+	}
+}
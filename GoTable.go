@@ -17,11 +17,11 @@ package main
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"strconv"
-
-	"github.com/olekukonko/tablewriter"
 )
 
 type converter2D interface {
@@ -77,37 +77,14 @@ func (ss string1D) convert1D() []interface{} {
 	return slice
 }
 
+// ConvertToString2D converts a 2D slice to strings using the type Formatters of DefaultFormats
 func ConvertToString2D(it [][]interface{}) [][]string {
-	slice := make([][]string, len(it))
-	for i, row := range it {
-		slice[i] = make([]string, len(row))
-		for j, cell := range row {
-			switch convert := cell.(type) {
-			case string:
-				slice[i][j] = convert
-			case int:
-				slice[i][j] = strconv.Itoa(convert)
-			case nil:
-				slice[i][j] = ""
-			}
-		}
-	}
-	return slice
+	return DefaultFormats.formatRows(nil, it)
 }
 
+// ConvertToString1D converts a slice to strings using the type Formatters of DefaultFormats
 func ConvertToString1D(it []interface{}) []string {
-	slice := make([]string, len(it))
-	for i, val := range it {
-		switch convert := val.(type) {
-		case string:
-			slice[i] = convert
-		case int:
-			slice[i] = strconv.Itoa(convert)
-		case nil:
-			slice[i] = ""
-		}
-	}
-	return slice
+	return DefaultFormats.formatSlice(nil, it)
 }
 
 // Table is the main struct which is defined by a header that is a slice of column names, an index of the NamedVector type, and body values.
@@ -115,6 +92,8 @@ type Table struct {
 	Header MappedSlice
 	Index  MappedSlice
 	Vals   [][]interface{}
+
+	categoricals map[interface{}]*Categorical // Categorical of each categorical column by label, so columns without values keep their categories. Set by inheritCategoricals wherever a table is built from others
 }
 
 type MappedSlice struct {
@@ -170,6 +149,69 @@ func (ms *MappedSlice) AddVal(val interface{}) {
 	ms.Length += 1 // add to length
 }
 
+// Copy returns a copy of the MappedSlice which does not share its Map or Slice with the original
+func (ms MappedSlice) Copy() MappedSlice {
+	ms0 := MappedSlice{Header: ms.Header, Length: ms.Length}
+	ms0.Slice = copy1D(ms.Slice)
+	ms0.Map = make(map[interface{}][]int, len(ms.Map))
+	for key, indices := range ms.Map {
+		ms0.Map[key] = append([]int(nil), indices...)
+	}
+	return ms0
+}
+
+// Copy returns a copy of the table. The Index and Header are always copied. If deep is false, the copy has its own slice of rows but the rows themselves are shared with the original, so setting a cell is visible in both tables. If deep is true, every row is copied as well
+func (t *Table) Copy(deep bool) *Table {
+	t0 := &Table{
+		Header: t.Header.Copy(),
+		Index:  t.Index.Copy(),
+		Vals:   make([][]interface{}, len(t.Vals))}
+	for i, row := range t.Vals {
+		if deep {
+			t0.Vals[i] = copy1D(row)
+		} else {
+			t0.Vals[i] = row
+		}
+	}
+	t0.inheritCategoricals(t)
+	return t0
+}
+
+// inheritCategoricals sets the categoricals of the table built from the tables in from: a column keeps the Categorical its label has in every table of from with a column of that label. Other columns, including new ones, get none
+func (t *Table) inheritCategoricals(from ...*Table) {
+	t.categoricals = nil
+	for _, label := range t.Header.Slice {
+		var c *Categorical
+		for _, f := range from {
+			if _, ok := f.Header.Map[label]; !ok {
+				continue
+			}
+			fc := f.categoricals[label]
+			if fc == nil || (c != nil && fc != c) {
+				c = nil
+				break
+			}
+			c = fc
+		}
+		if c != nil {
+			if t.categoricals == nil {
+				t.categoricals = make(map[interface{}]*Categorical)
+			}
+			t.categoricals[label] = c
+		}
+	}
+}
+
+// copy1D returns a copy of a slice
+func copy1D(slice []interface{}) []interface{} {
+	if slice == nil {
+		return nil
+	}
+	s := make([]interface{}, len(slice))
+	copy(s, slice)
+	return s
+}
+
 // FromSlice creates a table from a slice. If header is true, the first nested slice is taken as a list of column headers. If index is true, the first element of each slice is taken as the list of index values
 func FromSlice(c converter2D, header bool, index bool) *Table {
 	vals := convert2D(c) // converts to [][]interface{}
@@ -247,7 +289,7 @@ func FromCSVFile(path string, Header bool, Index bool) *Table {
 	return t
 }
 
-// ResetIndex resets the index to the sequential form and replaces Table.Index.Name with "Index"
+// ResetIndex returns a copy of the table with the index reset to the sequential form. The old index is moved into the columns under its Table.Index.Header name and the new index is named "Index"
 // +-------+--------+-----+-------+
 // | INDEX | STRING | INT | FLOAT |
 // +-------+--------+-----+-------+
@@ -258,57 +300,230 @@ func FromCSVFile(path string, Header bool, Index bool) *Table {
 // |     4 | wg     |  34 |    .8 |
 // |     5 | ret    |   4 |   9.6 |
 // +-------+--------+-----+-------+
-func (t *Table) ResetIndex() {
-	t = t.mergeBoth()
-	numRows := t.Index.Length
-	t.Index = CreateNumMS(0, numRows)
+func (t *Table) ResetIndex() *Table {
+	t0 := t.mergeBoth()
+	t0.Index = CreateNumMS(0, t0.Index.Length)
+	return t0
 }
 
-func (t *Table) SetIndex(column interface{}) {
-	var col string
+// ResetIndexInPlace is the same as ResetIndex but modifies the table in place
+func (t *Table) ResetIndexInPlace() {
+	*t = *t.ResetIndex()
+}
+
+// SetIndex returns a copy of the table with the given column (by label or position) set as the index. The old index is moved into the columns
+func (t *Table) SetIndex(column interface{}) *Table {
+	pos := t.colPosition(column)
+	ms := CreateMS(GetTranspose(t.Vals, pos), t.Header.Slice[pos])
+	t0 := t.mergeBoth() // transfer current index into cols
+	t0.Index = ms       // set new index
+	return t0.dropPositions(pos + 1)
+}
 
-	// standardize lookup of column to name (not index)
-	switch val := column.(type) {
-	case string:
-		col = val
-	case int: // set by index of column
-		col = t.Header.Slice[val].(string)
+// SetIndexInPlace is the same as SetIndex but modifies the table in place
+func (t *Table) SetIndexInPlace(column interface{}) {
+	*t = *t.SetIndex(column)
+}
+
+// DropCol returns a copy of the table without the given column (by label or position). Every column with the label is dropped
+func (t *Table) DropCol(column interface{}) *Table {
+	positions, err := t.Header.lookup(column)
+	if err != nil {
+		log.Fatalln(err)
 	}
-	ms := CreateMS(t.GetCols(column)[0], col)
-	t = t.mergeBoth() // transfer current index into cols
-	t.Index = ms      // set new index
-	t.DropCol(col)
+	return t.dropPositions(positions...)
 }
 
-func (t *Table) DropCol(column interface{}) {
-	outHeader := sliceWOelement(t.Header.Slice, column) // remove column name
-	*t = *t.GenSliceLoc(1, outHeader...)
+// dropPositions returns a copy of the table without the columns at the given positions
+func (t *Table) dropPositions(positions ...int) *Table {
+	drop := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		drop[pos] = true
+	}
+	keep := make([]int, 0, t.Header.Length)
+	for j := 0; j < t.Header.Length; j++ {
+		if !drop[j] {
+			keep = append(keep, j)
+		}
+	}
+	return t.SliceILoc(1, keep...)
 }
 
-// GetCols returns column values
-func (t *Table) GetCols(columns ...interface{}) [][]interface{} {
-	return SliceTranspose(t.GenSliceLoc(1, columns...).Vals)
+// DropColInPlace is the same as DropCol but modifies the table in place
+func (t *Table) DropColInPlace(column interface{}) {
+	*t = *t.DropCol(column)
 }
 
-// sliceWOelement returns a slice without a specific element
-func sliceWOelement(slice []interface{}, value interface{}) []interface{} {
-	outSlice := make([]interface{}, len(slice)-1)
-	var i int
-	for index, elem := range slice {
-		switch val := value.(type) {
-		case string:
-			if elem != val { // match element
-				outSlice[i] = elem
-				i++
+// Rename returns a copy of the table with labels on an axis renamed. mapper is either a map of old label to new label, of any key and value types (labels not in the map are kept), or a func(interface{}) interface{} which is called on every label. An error is returned for any other mapper
+func (t *Table) Rename(axis _Axis, mapper interface{}) (*Table, error) {
+	var f func(interface{}) interface{}
+	switch m := mapper.(type) {
+	case func(interface{}) interface{}:
+		f = m
+	default:
+		v := reflect.ValueOf(mapper)
+		if v.Kind() != reflect.Map {
+			return nil, fmt.Errorf("Mapper must be a map or a func(interface{}) interface{}, not %T", mapper)
+		}
+		keyType := v.Type().Key()
+		f = func(label interface{}) interface{} {
+			key := reflect.ValueOf(label)
+			if !key.IsValid() || !key.Type().AssignableTo(keyType) {
+				return label
 			}
-		case int: // do not return a specific index
-			if index != val {
-				outSlice[i] = elem
-				i++
+			if newLabel := v.MapIndex(key); newLabel.IsValid() {
+				return newLabel.Interface()
 			}
+			return label
+		}
+	}
+
+	ms := t.getAxisMS(axis)
+	labels := make([]interface{}, ms.Length)
+	for i, label := range ms.Slice {
+		labels[i] = f(label)
+	}
+
+	t0 := t.Copy(true)
+	t0.setAxisMS(axis, CreateMS(labels, ms.Header))
+	if axis == 1 && t.categoricals != nil {
+		t0.categoricals = make(map[interface{}]*Categorical, len(t.categoricals))
+		for label, c := range t.categoricals {
+			t0.categoricals[f(label)] = c
 		}
 	}
-	return outSlice
+	return t0, nil
+}
+
+// RenameInPlace is the same as Rename but modifies the table in place
+func (t *Table) RenameInPlace(axis _Axis, mapper interface{}) error {
+	t0, err := t.Rename(axis, mapper)
+	if err != nil {
+		return err
+	}
+	*t = *t0
+	return nil
+}
+
+// Reindex returns a new table conformed to the given labels on an axis. Labels which already exist keep their values (the first occurrence is used if a label is duplicated) and new labels are filled with fillValue
+func (t *Table) Reindex(axis _Axis, labels []interface{}, fillValue interface{}) *Table {
+	t0 := Table{}
+
+	ms := t.getAxisMS(axis)
+	opp := t.getAxisMS(axis.Opposite())
+	vals := getValsOrient(axis, t.Vals)
+	outVals := make([][]interface{}, len(labels))
+
+	for i, label := range labels {
+		if indices, ok := ms.Map[label]; ok {
+			outVals[i] = copy1D(vals[indices[0]])
+		} else {
+			outVals[i] = make([]interface{}, opp.Length)
+			for j := range outVals[i] {
+				outVals[i][j] = fillValue
+			}
+		}
+	}
+
+	t0.Index = CreateMS(labels, ms.Header)
+	t0.Header = opp.Copy()
+	t0.Vals = outVals
+
+	t1 := t0.getTableOrientation(axis)
+	t1.inheritCategoricals(t)
+	return t1
+}
+
+// InsertCol returns a copy of the table with a column inserted before position pos. A pos equal to the number of columns appends the column
+func (t *Table) InsertCol(pos int, name interface{}, values []interface{}) *Table {
+	t0 := t.Copy(true)
+	t0.InsertColInPlace(pos, name, values)
+	return t0
+}
+
+// InsertColInPlace is the same as InsertCol but modifies the table in place
+func (t *Table) InsertColInPlace(pos int, name interface{}, values []interface{}) {
+	if pos < 0 || pos > t.Header.Length {
+		log.Fatalln(errors.New("Position out of range"))
+	}
+	if len(values) != t.Index.Length {
+		log.Fatalln(errors.New("Length of values does not match length of index"))
+	}
+
+	header := make([]interface{}, 0, t.Header.Length+1)
+	header = append(header, t.Header.Slice[:pos]...)
+	header = append(header, name)
+	header = append(header, t.Header.Slice[pos:]...)
+	t.Header = CreateMS(header, t.Header.Header)
+	delete(t.categoricals, name) // the label now names a new column
+
+	for i, row := range t.Vals {
+		newRow := make([]interface{}, 0, len(row)+1)
+		newRow = append(newRow, row[:pos]...)
+		newRow = append(newRow, values[i])
+		newRow = append(newRow, row[pos:]...)
+		t.Vals[i] = newRow
+	}
+}
+
+// MoveCol returns a copy of the table with a column (by name or position) moved so that it ends up at position pos. If a name is duplicated, the first column with that name is moved
+func (t *Table) MoveCol(column interface{}, pos int) *Table {
+	from := t.colPosition(column)
+	if pos < 0 || pos >= t.Header.Length {
+		log.Fatalln(errors.New("Position out of range"))
+	}
+
+	order := make([]int, 0, t.Header.Length)
+	for i := 0; i < t.Header.Length; i++ {
+		if i != from {
+			order = append(order, i)
+		}
+	}
+	order = append(order[:pos], append([]int{from}, order[pos:]...)...)
+
+	return t.SliceILoc(1, order...)
+}
+
+// MoveColInPlace is the same as MoveCol but modifies the table in place
+func (t *Table) MoveColInPlace(column interface{}, pos int) {
+	*t = *t.MoveCol(column, pos)
+}
+
+// colPosition standardizes lookup of a column to its position. Labels resolve to the first column with that label
+func (t *Table) colPosition(column interface{}) int {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return pos
+}
+
+// lookupCol is the same as colPosition but returns an error instead of exiting when the column does not exist
+func (t *Table) lookupCol(column interface{}) (int, error) {
+	positions, err := t.Header.lookup(column)
+	if err != nil {
+		return -1, err
+	}
+	return positions[0], nil
+}
+
+// lookup returns the positions of a label of any type. An int which is not a label is taken as a position
+func (ms MappedSlice) lookup(value interface{}) ([]int, error) {
+	if indices, ok := ms.Map[value]; ok {
+		return indices, nil
+	}
+	if pos, ok := value.(int); ok {
+		if pos < 0 || pos >= ms.Length {
+			return nil, errors.New("Position out of range")
+		}
+		return []int{pos}, nil
+	}
+	return nil, errors.New("Key not found in map")
+}
+
+// GetCols returns column values
+func (t *Table) GetCols(columns ...interface{}) [][]interface{} {
+	return SliceTranspose(t.GenSliceLoc(1, columns...).Vals)
 }
 
 // rangeUntil creates an ascending slice of interfaces of strings (converted from int for later printing) until Index paramater
@@ -329,8 +544,11 @@ func GetTranspose(s [][]interface{}, index int) []interface{} {
 	return slice
 }
 
-// SliceTranspose transposes a 2D slice in its entirety using GetTranspose
+// SliceTranspose transposes a 2D slice in its entirety using GetTranspose. An empty slice transposes to an empty slice
 func SliceTranspose(s [][]interface{}) [][]interface{} {
+	if len(s) == 0 {
+		return [][]interface{}{}
+	}
 	slice := make([][]interface{}, len(s[0]))
 	for i := range s[0] {
 		slice[i] = GetTranspose(s, i)
@@ -338,13 +556,18 @@ func SliceTranspose(s [][]interface{}) [][]interface{} {
 	return slice
 }
 
-// Transpose transposes the entire table. indexHeader param is used to set the new indexHeader
+// Transpose returns a transposed copy of the entire table. The index becomes the header and vice versa
 func (t *Table) Transpose() *Table {
 	t0 := Table{}
 	t0.Vals = SliceTranspose(t.Vals)
-	ms := t.getAxisMS(1)
-	t0.Header = t.getAxisMS(0)
-	t0.Index = ms
+	if len(t.Vals) == 0 { // a table without rows transposes to one empty row per column
+		t0.Vals = make([][]interface{}, t.Header.Length)
+		for i := range t0.Vals {
+			t0.Vals[i] = []interface{}{}
+		}
+	}
+	t0.Header = t.Index.Copy()
+	t0.Index = t.Header.Copy()
 
 	return &t0
 }
@@ -373,28 +596,22 @@ func mergeIndex1D(index interface{}, vals []interface{}) []interface{} {
 	return v
 }
 
-// mergeBoth calls mergeIndex1D and mergeIndex2D and reassigns Table fields inplace. Table.Index.Header and Table.Header.Header remain unchanged
+// mergeBoth calls mergeIndex1D and mergeIndex2D and returns a new Table with the index merged into the columns. The receiver is left unchanged, as are Table.Index.Header and Table.Header.Header
 func (t *Table) mergeBoth() *Table {
-	t0 := t
+	t0 := &Table{Index: t.Index.Copy()}
 	newHeader := mergeIndex1D(t.Index.Header, t.Header.Slice)
 	t0.Header = CreateGenMS(1, newHeader)
 
 	newVals := mergeIndex2D(t.Index.Slice, t.Vals)
 	t0.Vals = newVals
+	t0.inheritCategoricals(t)
 
 	return t0
 }
 
-// PrintTable prints the table using non-std Ascii Table package
+// PrintTable prints the table to stdout as an ascii table. Cells are formatted using DefaultFormats
 func (t *Table) PrintTable() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader(
-		ConvertToString1D(
-			mergeIndex1D(t.Index.Header, t.Header.Slice)))
-	table.AppendBulk(
-		ConvertToString2D(
-			mergeIndex2D(t.Index.Slice, t.Vals))) // Add Bulk Data
-	table.Render()
+	t.Render(os.Stdout, RenderOptions{})
 }
 
 // SliceLoc returns table selections found on 1 axis by using name selections
@@ -402,14 +619,14 @@ func (t *Table) SliceLoc(axis _Axis, names ...string) *Table {
 	t0 := Table{}
 
 	ms := t.getAxisMS(axis)
-	vals := getValsOrient(axis, t.Vals)
+	vals := t.getTableOrientation(axis).Vals
 	var outVals [][]interface{}
 	var outNames []interface{}
 
 	for _, name := range names {
 		if indices, ok := ms.Map[name]; ok {
 			for _, index := range indices {
-				outVals = append(outVals, vals[index])
+				outVals = append(outVals, copy1D(vals[index]))
 				outNames = append(outNames, name)
 			}
 		} else {
@@ -419,13 +636,15 @@ func (t *Table) SliceLoc(axis _Axis, names ...string) *Table {
 
 	ms = CreateMS(outNames, ms.Header)
 	t0.Index = ms
-	t0.Header = t.getAxisMS(axis.Opposite())
+	t0.Header = t.getAxisMS(axis.Opposite()).Copy()
 	t0.Vals = outVals
 
-	return t0.getTableOrientation(axis)
+	t1 := t0.getTableOrientation(axis)
+	t1.inheritCategoricals(t)
+	return t1
 }
 
-// Loc uses name selections to find a selected subsections of indexed rows and columns on both axes
+// Loc uses name selections to find a selected subsections of indexed rows and columns on both axes. A new Table is always returned, even when no selections are made
 func (t *Table) Loc(rows []string, cols []string) *Table {
 	t0 := t
 	if len(rows) > 0 {
@@ -434,6 +653,9 @@ func (t *Table) Loc(rows []string, cols []string) *Table {
 	if len(cols) > 0 {
 		t0 = t0.SliceLoc(1, cols...)
 	}
+	if t0 == t {
+		return t.Copy(true)
+	}
 
 	return t0
 }
@@ -443,23 +665,25 @@ func (t *Table) SliceILoc(axis _Axis, indices ...int) *Table {
 	t0 := Table{}
 
 	ms := t.getAxisMS(axis)
-	vals := getValsOrient(axis, t.Vals)
+	vals := t.getTableOrientation(axis).Vals
 	outVals := make([][]interface{}, len(indices))
 	outNames := make([]interface{}, len(indices))
 
 	for i, index := range indices {
-		outVals[i] = vals[index]
+		outVals[i] = copy1D(vals[index])
 		outNames[i] = ms.Slice[index]
 	}
 
 	t0.Index = CreateMS(outNames, ms.Header)
-	t0.Header = t.getAxisMS(axis.Opposite())
+	t0.Header = t.getAxisMS(axis.Opposite()).Copy()
 	t0.Vals = outVals
 
-	return t0.getTableOrientation(axis)
+	t1 := t0.getTableOrientation(axis)
+	t1.inheritCategoricals(t)
+	return t1
 }
 
-// ILoc uses index selections to find a selected subsections of indexed rows and columns on both axes
+// ILoc uses index selections to find a selected subsections of indexed rows and columns on both axes. A new Table is always returned, even when no selections are made
 func (t *Table) ILoc(rows []int, cols []int) *Table {
 	t0 := t
 	if len(rows) > 0 {
@@ -468,34 +692,36 @@ func (t *Table) ILoc(rows []int, cols []int) *Table {
 	if len(cols) > 0 {
 		t0 = t0.SliceILoc(1, cols...)
 	}
+	if t0 == t {
+		return t.Copy(true)
+	}
 
 	return t0
-
 }
 
-// GenSliceLoc returns selections found on 1 axis by using 1 or more selectors of interface types (both string and int can be used). GenSliceLoc combines the functionality of SliceLoc and SliceILoc
+// GenSliceLoc returns selections found on 1 axis by using 1 or more selectors of interface types. Labels of any type select every slice with that label and ints which are not labels select by position. GenSliceLoc combines the functionality of SliceLoc and SliceILoc
 func (t *Table) GenSliceLoc(axis _Axis, values ...interface{}) *Table {
-	t0 := &Table{}
-	t0.Index.Header = t.getAxisMS(axis).Header
-	t0.Header = t.getAxisMS(axis.Opposite())
-	t1 := &Table{}
-
+	ms := t.getAxisMS(axis)
+	var positions []int
 	for _, val := range values {
-		switch v := val.(type) {
-		case string:
-			t1 = t.SliceLoc(axis, v)
-		case int:
-			t1 = t.SliceILoc(axis, v)
+		indices, err := ms.lookup(val)
+		if err != nil {
+			log.Fatalln(err)
 		}
-		t1 = t1.getTableOrientation(axis)
-		t0.AddSlice(0, t1.getAxisMS(0).Slice[0], getValsOrient(0, t1.Vals)[0])
+		positions = append(positions, indices...)
 	}
+	return t.SliceILoc(axis, positions...)
+}
 
-	return t0.getTableOrientation(axis)
+// AddSlice returns a copy of the table with the slice appended to the end of the given axis
+func (t *Table) AddSlice(axis _Axis, header interface{}, slice []interface{}) *Table {
+	t0 := t.Copy(false)
+	t0.AddSliceInPlace(axis, header, slice)
+	return t0
 }
 
-// AddSlice appends to the end of the table on an axis
-func (t *Table) AddSlice(axis _Axis, header interface{}, slice []interface{}) {
+// AddSliceInPlace appends to the end of the table on an axis, modifying the table in place
+func (t *Table) AddSliceInPlace(axis _Axis, header interface{}, slice []interface{}) {
 	var ms MappedSlice
 	if axis == 0 {
 		ms = t.Index
@@ -507,10 +733,11 @@ func (t *Table) AddSlice(axis _Axis, header interface{}, slice []interface{}) {
 		ms.AddVal(header)
 		t.Vals = SliceTranspose(append(SliceTranspose(t.Vals), slice))
 		t.Header = ms
+		delete(t.categoricals, header) // the label now names a new column
 	}
 }
 
-// // PairedSliceLoc is similar to the other SliceLoc functions but returns the passed in names and the results. PairedSliceLoc does not panic but instead returns empty slices where it could not find a name.
+// // PairedSliceLoc is similar to the other SliceLoc functions but returns the passed in names and the results. PairedSliceLoc does not panic but instead returns empty slices where it could not find a name. The returned slices are copies of the table's values
 // [efe efe trer hello] [[3 5.32] [2 1.32] [<nil> <nil>] [<nil> <nil>]]
 func (t *Table) PairedSliceLoc(axis _Axis, vals ...interface{}) ([]interface{}, [][]interface{}) {
 	slice := t.Vals
@@ -524,7 +751,7 @@ func (t *Table) PairedSliceLoc(axis _Axis, vals ...interface{}) ([]interface{},
 		if err {
 			for _, index := range indices {
 				if axis == 0 {
-					outSlice = append(outSlice, slice[index])
+					outSlice = append(outSlice, copy1D(slice[index]))
 				} else if axis == 1 {
 					outSlice = append(outSlice, GetTranspose(slice, index))
 				}
@@ -549,43 +776,6 @@ func Index(search interface{}, slice []interface{}) (int, error) {
 	return -1, errors.New("Search not found")
 }
 
-func createDupeMap(m map[interface{}]interface{}, keys []interface{}, slices [][]interface{}, length int, totalLength int, index *int) ([]interface{}, map[interface{}]interface{}) {
-	counter := make(map[interface{}]int)
-	newKeys := make([]interface{}, len(keys))
-
-	for i := 0; i < len(keys); i++ {
-		key := keys[i]
-		var k int
-		if _, ok := m[key]; !ok { // if not in map
-			m[key] = make([]interface{}, totalLength) // make nested slice which is the length of the axis
-			for j := *index; j < *index+length; j++ { // access the correct nested slice
-				m[key].([]interface{})[j] = slices[i][k] // change each element of the nested slice
-				k++
-			}
-			counter[key] = 1
-		} else if counter[key] == 0 { // if key exists in map but we are at a new table and counter has reset
-			for j := *index; j < *index+length; j++ {
-				m[key].([]interface{})[j] = slices[i][k]
-				k++
-			}
-			counter[key] = 1
-		} else { // key exists and counter has not been reset
-			key = key.(string) + "_" + strconv.Itoa(counter[key]) // create dupe key name
-			if _, ok := m[key]; !ok {                             // create slice for key if its not already there
-				m[key] = make([]interface{}, totalLength)
-			}
-			for j := *index; j < *index+length; j++ { // change each element of nested list
-				m[key].([]interface{})[j] = slices[i][k]
-				k++
-			}
-		}
-		k = 0 // reset the index position of slice
-		newKeys[i] = key
-	}
-	*index += length
-	return newKeys, m
-}
-
 func appendMapSlices(m map[interface{}]interface{}, key interface{}, slices []interface{}) map[interface{}]interface{} {
 	if _, ok := m[key]; !ok {
 		m[key] = slices
@@ -613,17 +803,59 @@ func spacer(length int, slice []interface{}) []interface{} {
 	return s
 }
 
+// getuniqs returns the distinct values of a slice in order of first appearance. Missing values count as a single value, kept as the first one found
 func getuniqs(slice []interface{}) []interface{} {
 	uniqs := make([]interface{}, 0)
+	seen := make(map[interface{}]bool)
 	for _, val := range slice {
-		if _, err := Index(val, uniqs); err != nil {
+		if key := hashKey(val); !seen[key] {
+			seen[key] = true
 			uniqs = append(uniqs, val)
 		}
 	}
 	return uniqs
 }
 
-// Concat concantenates multiple tables along a given axis and merges common rows and columns. If there are duplicates which interfere with merging, the first dupe is merged with and the subsequent one is renamed in a sequential fashion i.e. name -> name_1
+// naKey is the map key standing for every missing value, since NaN is never equal to itself
+type naKey struct{}
+
+// hashKey returns the key a value is looked up by in a map of distinct values
+func hashKey(val interface{}) interface{} {
+	if IsNA(val) {
+		return naKey{}
+	}
+	return val
+}
+
+// Join decides which labels Concat keeps on the axis it aligns
+type Join uint8
+
+const (
+	JoinOuter Join = iota // keep the labels of every table, filling gaps with nil
+	JoinInner             // keep only the labels found in every table
+)
+
+// ConcatOptions configures Concat
+type ConcatOptions struct {
+	Axis            _Axis         // 0 aligns rows by index and places the columns side by side, 1 stacks the rows and aligns columns by header
+	Join            Join          // labels kept on the aligned axis
+	IgnoreIndex     bool          // number the labels of the concatenated axis sequentially instead of keeping them
+	Keys            []interface{} // one key per table, combined with the labels of the concatenated axis into MultiLabels
+	VerifyIntegrity bool          // return an error if the concatenated axis ends up with duplicate labels
+}
+
+// MultiLabel is a hierarchical label, as built by Concat with Keys. Nesting MultiLabels in Label gives further levels
+type MultiLabel struct {
+	Key   interface{}
+	Label interface{}
+}
+
+// String formats the label as (key, label)
+func (ml MultiLabel) String() string {
+	return "(" + DefaultFormats.Format(nil, ml.Key) + ", " + DefaultFormats.Format(nil, ml.Label) + ")"
+}
+
+// Concat concatenates multiple tables along the axis given in opts while aligning the other axis by label, keeping labels in order of first appearance. Duplicate labels align by occurrence, ie. the second "efe" of one table with the second "efe" of the next, and are never renamed. The names of both MappedSlices are taken from the first table
 // +--------+-----+-------+
 // | STRING | INT | FLOAT |
 // +--------+-----+-------+
@@ -647,20 +879,20 @@ func getuniqs(slice []interface{}) []interface{} {
 // | vin    |   9 |  1.23 |
 // +--------+-----+-------+
 // 			  (=)
-// axis == 0:
-// --------+-----+-------+-----+-------+
+// Axis == 0:
+// +--------+-----+-------+-----+-------+
 // | STRING | INT | FLOAT | INT | FLOAT |
 // +--------+-----+-------+-----+-------+
 // | eff    |   1 |   4.2 |   2 |  34.3 |
 // | efe    |   3 |  5.32 |   8 |   7.2 |
-// | efe_1  |   2 |  1.32 |   2 |   6.2 |
+// | efe    |   2 |  1.32 |   2 |   6.2 |
 // | ffs    |  52 |   2.1 |   4 |  7.47 |
 // | wg     |  34 |    .8 |   5 |   7.5 |
 // | ret    |   4 |   9.6 |     |       |
 // | gr     |     |       |   8 |  56.7 |
 // | vin    |     |       |   9 |  1.23 |
 // +--------+-----+-------+-----+-------+
-// axis == 1:
+// Axis == 1:
 // +--------+-----+-------+
 // | STRING | INT | FLOAT |
 // +--------+-----+-------+
@@ -678,46 +910,125 @@ func getuniqs(slice []interface{}) []interface{} {
 // | gr     |   8 |  56.7 |
 // | vin    |   9 |  1.23 |
 // +--------+-----+-------+
-func Concat(axis _Axis, tables ...*Table) *Table {
-	t := tables[0]
-	m := make(map[interface{}]interface{})
-
-	var totalLength int
-	var newHeaderVals []interface{}
-	for _, table := range tables {
-		locMS := table.getAxisMS(axis)
-		totalLength += locMS.Length
-		newHeaderVals = append(newHeaderVals, locMS.Slice...) // append all header names together
+func Concat(tables []*Table, opts ConcatOptions) (*Table, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("No tables to concatenate")
+	}
+	if opts.Axis != 0 && opts.Axis != 1 {
+		return nil, errors.New("Axis must be 0 or 1")
+	}
+	if opts.Keys != nil && len(opts.Keys) != len(tables) {
+		return nil, fmt.Errorf("%d keys given for %d tables", len(opts.Keys), len(tables))
 	}
 
-	indexVals := make([]interface{}, 0)
-	var newNames []interface{}
+	// work on tables oriented so the rows are concatenated and the columns aligned
+	stack := make([]*Table, len(tables))
+	for i, table := range tables {
+		stack[i] = table
+		if opts.Axis == 0 {
+			stack[i] = table.Transpose()
+		}
+	}
 
-	currIndex := 0
-	indexptr := &currIndex
+	headers := make([]MappedSlice, len(stack))
+	for i, table := range stack {
+		headers[i] = table.Header
+	}
+	header, positions := alignLabels(headers...)
+	columns := make([]int, 0, len(header)) // positions in header of the columns kept
+	for j := range header {
+		found := 0
+		for i := range stack {
+			if positions[i][j] >= 0 {
+				found++
+			}
+		}
+		if opts.Join != JoinInner || found == len(stack) {
+			columns = append(columns, j)
+		}
+	}
 
-	ms1 := t.getAxisMS(axis)
-	axis = axis.Opposite()
-	ms2 := t.getAxisMS(axis)
-	for _, table := range tables {
-		locMS1 := table.getAxisMS(axis)
-		locMS2 := table.getAxisMS(axis.Opposite())
-		uniqs := getuniqs(locMS1.Slice)
-		names, slices := table.PairedSliceLoc(axis, uniqs...)
+	var labels []interface{}
+	var vals [][]interface{}
+	for i, table := range stack {
+		for r, row := range table.Vals {
+			newRow := make([]interface{}, len(columns))
+			for j, col := range columns {
+				if pos := positions[i][col]; pos >= 0 {
+					newRow[j] = row[pos]
+				}
+			}
+			vals = append(vals, newRow)
 
-		newNames, m = createDupeMap(m, names, slices, locMS2.Length, totalLength, indexptr)
-		indexVals = append(indexVals, newNames...)
+			label := table.Index.Slice[r]
+			if opts.Keys != nil {
+				label = MultiLabel{opts.Keys[i], label}
+			}
+			labels = append(labels, label)
+		}
 	}
 
-	indexVals = getuniqs(indexVals)
+	kept := make([]interface{}, len(columns))
+	for j, col := range columns {
+		kept[j] = header[col]
+	}
+	t := &Table{
+		Header: CreateMS(kept, stack[0].Header.Header),
+		Index:  CreateMS(labels, stack[0].Index.Header),
+		Vals:   vals}
+	if opts.IgnoreIndex {
+		t.Index = CreateMS(rangeUntil(len(vals)), stack[0].Index.Header)
+	}
+	if opts.VerifyIntegrity {
+		for _, label := range t.Index.Slice {
+			if indices := t.Index.Map[label]; len(indices) > 1 {
+				return nil, fmt.Errorf("Label %v is duplicated at positions %v", label, indices)
+			}
+		}
+	}
+	if opts.Axis == 0 {
+		t = t.Transpose()
+	}
+	t.inheritCategoricals(tables...)
+	return t, nil
+}
 
-	t = FromMap(0, m)
-	t.Index.Header = ms2.Header
-	t.Header = CreateMS(newHeaderVals, ms1.Header)
-	t = t.GenSliceLoc(0, indexVals...) // needed to rearrange into original order after map disrupts order
-	t = t.getTableOrientation(axis)    // flip if axis == 1
+// alignLabels lines up the labels of several MappedSlices. Labels are matched by value and occurrence, so the second "efe" of one matches the second "efe" of the next. It returns every label in order of first appearance, along with the position of each label in every MappedSlice, -1 where it is missing
+func alignLabels(mss ...MappedSlice) ([]interface{}, [][]int) {
+	type occurrence struct {
+		label interface{}
+		n     int
+	}
+	var labels []interface{}
+	found := make(map[occurrence]int) // position in labels
+	keys := make([][]int, len(mss))    // position in labels of every label of every MappedSlice
+	for i, ms := range mss {
+		keys[i] = make([]int, len(ms.Slice))
+		counts := make(map[interface{}]int)
+		for j, label := range ms.Slice {
+			occ := occurrence{label, counts[label]}
+			counts[label]++
+			k, ok := found[occ]
+			if !ok {
+				k = len(labels)
+				found[occ] = k
+				labels = append(labels, label)
+			}
+			keys[i][j] = k
+		}
+	}
 
-	return t
+	positions := make([][]int, len(mss))
+	for i := range mss {
+		positions[i] = make([]int, len(labels))
+		for k := range positions[i] {
+			positions[i][k] = -1
+		}
+		for j, k := range keys[i] {
+			positions[i][k] = j
+		}
+	}
+	return labels, positions
 }
 
 func (t *Table) getAxisMS(axis _Axis) (ms MappedSlice) {
@@ -729,6 +1040,15 @@ func (t *Table) getAxisMS(axis _Axis) (ms MappedSlice) {
 	return
 }
 
+// setAxisMS replaces the MappedSlice of the given axis
+func (t *Table) setAxisMS(axis _Axis, ms MappedSlice) {
+	if axis == 0 {
+		t.Index = ms
+	} else if axis == 1 {
+		t.Header = ms
+	}
+}
+
 // getValsOrient returns vals in the orientation based on the passed in axis
 func getValsOrient(axis _Axis, vals [][]interface{}) [][]interface{} {
 	if axis == 1 {
@@ -778,23 +1098,30 @@ func Axis(axis _Axis) _Axis {
 	return a
 }
 
-// ToMap converts a Table to a map along a given axis (discards other axis)
+// ToMap converts a Table to a map along a given axis (discards other axis). The slices in the map are copies, so modifying them leaves the table unchanged
 func (t *Table) ToMap(axis _Axis) map[interface{}]interface{} {
 	m := make(map[interface{}]interface{})
 
 	var labels MappedSlice
 	labels = t.getAxisMS(axis)
+	vals := getValsOrient(axis, t.Vals)
 	for i := 0; i < len(labels.Slice); i++ {
-		m[labels.Slice[i]] = getValsOrient(axis, t.Vals)[i]
+		m[labels.Slice[i]] = copy1D(vals[i])
 	}
 
 	// headers
 	labels = t.getAxisMS(axis.Opposite())
-	m[labels.Header] = labels.Slice
+	m[labels.Header] = copy1D(labels.Slice)
 
 	return m
 }
 
+// keys under which the parquet and arrow formats store the index and header names of a Table in their key-value metadata
+const (
+	indexMetadataKey  = "gotable.index"
+	headerMetadataKey = "gotable.columns"
+)
+
 // ToSlice converts a Table to a slice
 func (t *Table) ToSlice() [][]interface{} {
 	t0 := t.mergeBoth()
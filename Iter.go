@@ -0,0 +1,59 @@
+package main
+
+import (
+	"iter"
+)
+
+// Row is a single row of a Table. Values is the row of Table.Vals itself, not a copy
+type Row struct {
+	Label  interface{}
+	Values []interface{}
+
+	table *Table
+}
+
+// Get returns the value of the row in a column (by name or position). A duplicated name returns the value in its first column
+func (r Row) Get(column interface{}) (interface{}, error) {
+	pos, err := r.table.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	return r.Values[pos], nil
+}
+
+// IterRows returns an iterator over the index labels and rows of the table for use with range. Rows are not copied, so changes to Row.Values change the table
+func (t *Table) IterRows() iter.Seq2[interface{}, Row] {
+	return func(yield func(interface{}, Row) bool) {
+		for i, row := range t.Vals {
+			if !yield(t.Index.Slice[i], Row{Label: t.Index.Slice[i], Values: row, table: t}) {
+				return
+			}
+		}
+	}
+}
+
+// IterCols returns an iterator over the header names and columns of the table for use with range. Each column is built only when it is reached and shares the Index of the table, which must not be modified in place
+func (t *Table) IterCols() iter.Seq2[interface{}, Series[interface{}]] {
+	return func(yield func(interface{}, Series[interface{}]) bool) {
+		for j, name := range t.Header.Slice {
+			values := make([]interface{}, len(t.Vals))
+			for i, row := range t.Vals {
+				values[i] = row[j]
+			}
+			if !yield(name, Series[interface{}]{Name: name, Index: t.Index, Values: values}) {
+				return
+			}
+		}
+	}
+}
+
+// Itertuples returns an iterator over the rows of the table for use with range, each as a new slice holding the index label followed by the values of the row
+func (t *Table) Itertuples() iter.Seq[[]interface{}] {
+	return func(yield func([]interface{}) bool) {
+		for i, row := range t.Vals {
+			if !yield(mergeIndex1D(t.Index.Slice[i], row)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,887 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+// ParquetCodec is the compression applied to the pages written by WriteParquet
+type ParquetCodec uint8
+
+const (
+	ParquetUncompressed ParquetCodec = iota
+	ParquetSnappy
+)
+
+// parquet physical types
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetInt96     = 3
+	parquetFloat     = 4
+	parquetDouble    = 5
+	parquetByteArray = 6
+	parquetFixedLen  = 7
+)
+
+// parquet converted types
+const (
+	parquetUTF8            = 0
+	parquetEnum            = 4
+	parquetDate            = 6
+	parquetTimestampMillis = 9
+	parquetTimestampMicros = 10
+	parquetJSON            = 19
+)
+
+// parquet encodings
+const (
+	parquetPlain           = 0
+	parquetPlainDictionary = 2
+	parquetRLE             = 3
+	parquetRLEDictionary   = 8
+)
+
+// parquet page types
+const (
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+)
+
+// parquet compression codecs
+const (
+	parquetCodecUncompressed = 0
+	parquetCodecSnappy       = 1
+	parquetCodecGzip         = 2
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn describes a flat column of a parquet schema
+type parquetColumn struct {
+	name      string
+	typ       int64
+	optional  bool
+	converted int64 // -1 if not set
+	logical   thriftStruct
+	typeLen   int
+}
+
+// ReadParquet creates a Table from a .parquet file. If columns is not empty, only those columns are read, along with the index, which is never read twice when named in columns. Columns keep their physical type (bool, int32, int64, float32, float64, string for UTF8 byte arrays and []byte otherwise), dates and timestamps become time.Time in UTC and null values are nil. A file written by WriteParquet gets its index back, any other file gets a sequential index. Only flat schemas are supported
+func ReadParquet(path string, columns []string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < 12 {
+		return nil, errors.New("File is too small to be parquet")
+	}
+
+	tail := make([]byte, 8)
+	if _, err := file.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	head := make([]byte, 4)
+	if _, err := file.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tail[4:], parquetMagic) || !bytes.Equal(head, parquetMagic) {
+		return nil, errors.New("File is not parquet")
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if footerLen > size-12 {
+		return nil, errors.New("Invalid parquet footer length")
+	}
+	footer := make([]byte, footerLen)
+	if _, err := file.ReadAt(footer, size-8-footerLen); err != nil {
+		return nil, err
+	}
+	meta, err := (&thriftDecoder{buf: footer}).readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := parquetSchema(meta.list(2))
+	if err != nil {
+		return nil, err
+	}
+	kv := make(map[string]string)
+	for _, item := range meta.list(5) {
+		pair, ok := item.(thriftStruct)
+		if !ok {
+			return nil, errors.New("Invalid parquet key value metadata")
+		}
+		kv[pair.str(1)] = pair.str(2)
+	}
+
+	// select columns, always keeping the index
+	indexName, hasIndex := kv[indexMetadataKey]
+	positions := make(map[string]int)
+	for i, col := range schema {
+		positions[col.name] = i
+	}
+	if _, ok := positions[indexName]; !ok {
+		hasIndex = false
+	}
+	var selected []int
+	if hasIndex {
+		selected = append(selected, positions[indexName])
+	}
+	if len(columns) == 0 {
+		for i, col := range schema {
+			if !hasIndex || col.name != indexName {
+				selected = append(selected, i)
+			}
+		}
+	} else {
+		for _, name := range columns {
+			i, ok := positions[name]
+			if !ok {
+				return nil, fmt.Errorf("Column %q not found", name)
+			}
+			if hasIndex && name == indexName {
+				continue // already read as the index
+			}
+			selected = append(selected, i)
+		}
+	}
+
+	if meta.int(3) < 0 || meta.int(3) > math.MaxInt32 {
+		return nil, errors.New("Invalid parquet row count")
+	}
+	numRows := int(meta.int(3))
+	colVals := make([][]interface{}, len(selected))
+	for _, item := range meta.list(4) {
+		rg, ok := item.(thriftStruct)
+		if !ok {
+			return nil, errors.New("Invalid parquet row group")
+		}
+		chunks := rg.list(1)
+		for i, pos := range selected {
+			if pos >= len(chunks) {
+				return nil, errors.New("Row group is missing columns")
+			}
+			chunk, ok := chunks[pos].(thriftStruct)
+			if !ok {
+				return nil, errors.New("Invalid parquet column chunk")
+			}
+			vals, err := readParquetChunk(file, size, chunk.strct(3), schema[pos], numRows-len(colVals[i]))
+			if err != nil {
+				return nil, fmt.Errorf("Column %s: %v", schema[pos].name, err)
+			}
+			colVals[i] = append(colVals[i], vals...)
+		}
+	}
+	for i, pos := range selected {
+		if len(colVals[i]) != numRows {
+			return nil, fmt.Errorf("Column %s has %d values for %d rows", schema[pos].name, len(colVals[i]), numRows)
+		}
+	}
+
+	header := make([]interface{}, 0, len(selected))
+	for _, pos := range selected {
+		header = append(header, schema[pos].name)
+	}
+	t := &Table{Index: CreateNumMS(0, numRows)}
+	if hasIndex {
+		for _, val := range colVals[0] {
+			if val != nil && !reflect.TypeOf(val).Comparable() {
+				return nil, fmt.Errorf("Index %s has %T values, which cannot be labels", indexName, val)
+			}
+		}
+		t.Index = CreateMS(colVals[0], indexName)
+		header = header[1:]
+		colVals = colVals[1:]
+	}
+	t.Header = CreateGenMS(1, header)
+	if name, ok := kv[headerMetadataKey]; ok {
+		t.Header.Header = name
+	}
+	t.Vals = make([][]interface{}, numRows)
+	for i := range t.Vals {
+		t.Vals[i] = make([]interface{}, len(colVals))
+		for j := range colVals {
+			t.Vals[i][j] = colVals[j][i]
+		}
+	}
+	return t, nil
+}
+
+// parquetSchema flattens the schema elements of a file into columns
+func parquetSchema(elements []interface{}) ([]parquetColumn, error) {
+	if len(elements) == 0 {
+		return nil, errors.New("Parquet schema is empty")
+	}
+	var schema []parquetColumn
+	for _, item := range elements[1:] {
+		el, ok := item.(thriftStruct)
+		if !ok {
+			return nil, errors.New("Invalid parquet schema element")
+		}
+		if el.int(5) > 0 || !el.has(1) {
+			return nil, errors.New("Nested parquet schemas are not supported")
+		}
+		if el.int(3) == 2 {
+			return nil, errors.New("Repeated parquet columns are not supported")
+		}
+		col := parquetColumn{
+			name:      el.str(4),
+			typ:       el.int(1),
+			optional:  el.int(3) == 1,
+			converted: -1,
+			logical:   el.strct(10),
+			typeLen:   int(el.int(2))}
+		if col.typ == parquetFixedLen && (el.int(2) < 0 || el.int(2) > math.MaxInt32) {
+			return nil, fmt.Errorf("Column %s has an invalid type length", col.name)
+		}
+		if el.has(6) {
+			col.converted = el.int(6)
+		}
+		schema = append(schema, col)
+	}
+	return schema, nil
+}
+
+// readParquetChunk reads every page of a column chunk of a file of fileSize bytes and returns its values, of which there can be at most maxValues
+func readParquetChunk(r io.ReaderAt, fileSize int64, meta thriftStruct, col parquetColumn, maxValues int) ([]interface{}, error) {
+	start := meta.int(9)
+	if meta.has(11) && meta.int(11) > 0 && meta.int(11) < start {
+		start = meta.int(11)
+	}
+	length := meta.int(7)
+	if start < 0 || length < 0 || start > fileSize || length > fileSize-start {
+		return nil, errors.New("Column chunk extends past the file")
+	}
+	buf := make([]byte, length)
+	if _, err := r.ReadAt(buf, start); err != nil {
+		return nil, err
+	}
+	codec := meta.int(4)
+	if meta.int(5) < 0 || meta.int(5) > int64(maxValues) {
+		return nil, errors.New("Invalid number of values in column chunk")
+	}
+	numValues := int(meta.int(5))
+
+	var dict []interface{}
+	vals := make([]interface{}, 0, numValues)
+	d := &thriftDecoder{buf: buf}
+	for len(vals) < numValues && d.pos < len(buf) {
+		header, err := d.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		if header.int(3) < 0 || header.int(3) > int64(len(buf)-d.pos) {
+			return nil, errors.New("Page extends past the column chunk")
+		}
+		size := int(header.int(3))
+		page := buf[d.pos : d.pos+size]
+		d.pos += size
+
+		switch header.int(1) {
+		case parquetDictionaryPage:
+			data, err := parquetDecompress(codec, page, int(header.int(2)))
+			if err != nil {
+				return nil, err
+			}
+			dict, _, err = decodeParquetPlain(data, col, int(header.strct(7).int(1)))
+			if err != nil {
+				return nil, err
+			}
+		case parquetDataPage:
+			dph := header.strct(5)
+			data, err := parquetDecompress(codec, page, int(header.int(2)))
+			if err != nil {
+				return nil, err
+			}
+			if dph.int(1) < 0 || dph.int(1) > int64(numValues-len(vals)) {
+				return nil, errors.New("Invalid number of values in page")
+			}
+			n := int(dph.int(1))
+			defs := []int(nil)
+			if col.optional {
+				if len(data) < 4 {
+					return nil, errors.New("Invalid definition levels")
+				}
+				length := int(binary.LittleEndian.Uint32(data))
+				if length > len(data)-4 {
+					return nil, errors.New("Invalid definition levels")
+				}
+				if defs, err = decodeHybrid(data[4:4+length], 1, n); err != nil {
+					return nil, err
+				}
+				data = data[4+length:]
+			}
+			page, err := decodeParquetPage(data, dph.int(2), col, dict, defs, n)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, page...)
+		case parquetDataPageV2:
+			dph := header.strct(8)
+			if dph.int(1) < 0 || dph.int(1) > int64(numValues-len(vals)) {
+				return nil, errors.New("Invalid number of values in page")
+			}
+			n := int(dph.int(1))
+			if dph.int(5) < 0 || dph.int(6) < 0 || dph.int(5) > int64(len(page)) || dph.int(6) > int64(len(page))-dph.int(5) {
+				return nil, errors.New("Invalid level lengths")
+			}
+			repLen, defLen := int(dph.int(6)), int(dph.int(5))
+			defs := []int(nil)
+			if col.optional {
+				if defs, err = decodeHybrid(page[repLen:repLen+defLen], 1, n); err != nil {
+					return nil, err
+				}
+			}
+			data := page[repLen+defLen:]
+			if !dph.has(7) || dph.bool(7) {
+				if data, err = parquetDecompress(codec, data, int(header.int(2))-repLen-defLen); err != nil {
+					return nil, err
+				}
+			}
+			page, err := decodeParquetPage(data, dph.int(4), col, dict, defs, n)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, page...)
+		}
+	}
+	return vals, nil
+}
+
+// parquetDecompress decompresses a page with the codec of its column chunk. size is the uncompressed size of the page, which the data may not exceed
+func parquetDecompress(codec int64, data []byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, errors.New("Invalid uncompressed page size")
+	}
+	errSize := errors.New("Page is larger than its uncompressed size")
+	switch codec {
+	case parquetCodecUncompressed:
+		return data, nil
+	case parquetCodecSnappy:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > size {
+			return nil, errSize
+		}
+		return snappy.Decode(nil, data)
+	case parquetCodecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, int64(size)+1))
+		if err == nil && len(out) > size {
+			err = errSize
+		}
+		return out, err
+	}
+	return nil, fmt.Errorf("Unsupported parquet codec %d", codec)
+}
+
+// decodeParquetPage decodes the values of a data page and spreads them over the defined positions. defs is nil for required columns
+func decodeParquetPage(data []byte, encoding int64, col parquetColumn, dict []interface{}, defs []int, n int) ([]interface{}, error) {
+	defined := n
+	if defs != nil {
+		defined = 0
+		for _, def := range defs {
+			defined += def
+		}
+	}
+
+	var vals []interface{}
+	var err error
+	switch encoding {
+	case parquetPlain:
+		vals, _, err = decodeParquetPlain(data, col, defined)
+	case parquetPlainDictionary, parquetRLEDictionary:
+		if dict == nil {
+			return nil, errors.New("Dictionary page is missing")
+		}
+		if len(data) == 0 {
+			return nil, errors.New("Invalid dictionary indices")
+		}
+		var indices []int
+		if indices, err = decodeHybrid(data[1:], int(data[0]), defined); err != nil {
+			return nil, err
+		}
+		vals = make([]interface{}, defined)
+		for i, index := range indices {
+			if index >= len(dict) {
+				return nil, errors.New("Dictionary index out of range")
+			}
+			vals[i] = dict[index]
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported parquet encoding %d", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range vals {
+		vals[i] = parquetLogical(val, col)
+	}
+	if defs == nil {
+		return vals, nil
+	}
+	out := make([]interface{}, n)
+	var j int
+	for i, def := range defs {
+		if def == 1 {
+			out[i] = vals[j]
+			j++
+		}
+	}
+	return out, nil
+}
+
+// decodeParquetPlain decodes n plain encoded values of a physical type and returns the number of bytes read
+func decodeParquetPlain(data []byte, col parquetColumn, n int) ([]interface{}, int, error) {
+	if n < 0 || n > len(data)*8 { // every value takes at least a bit
+		return nil, 0, errors.New("Invalid number of values")
+	}
+	vals := make([]interface{}, n)
+	var pos int
+	need := func(size int) error {
+		if pos+size > len(data) {
+			return errors.New("Not enough data for values")
+		}
+		return nil
+	}
+	for i := range vals {
+		switch col.typ {
+		case parquetBoolean:
+			if i/8 >= len(data) {
+				return nil, 0, errors.New("Not enough data for values")
+			}
+			vals[i] = data[i/8]>>(i%8)&1 == 1
+			pos = i/8 + 1
+		case parquetInt32:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = int32(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+		case parquetInt64:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = int64(binary.LittleEndian.Uint64(data[pos:]))
+			pos += 8
+		case parquetInt96:
+			if err := need(12); err != nil {
+				return nil, 0, err
+			}
+			nanos := int64(binary.LittleEndian.Uint64(data[pos:]))
+			julian := int64(binary.LittleEndian.Uint32(data[pos+8:]))
+			vals[i] = time.Unix((julian-2440588)*86400, nanos).UTC()
+			pos += 12
+		case parquetFloat:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+		case parquetDouble:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[pos:]))
+			pos += 8
+		case parquetByteArray:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			size := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if err := need(size); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = append([]byte(nil), data[pos:pos+size]...)
+			pos += size
+		case parquetFixedLen:
+			if err := need(col.typeLen); err != nil {
+				return nil, 0, err
+			}
+			vals[i] = append([]byte(nil), data[pos:pos+col.typeLen]...)
+			pos += col.typeLen
+		default:
+			return nil, 0, fmt.Errorf("Unsupported parquet type %d", col.typ)
+		}
+	}
+	return vals, pos, nil
+}
+
+// parquetLogical converts a physical value to the Go type of its logical type
+func parquetLogical(val interface{}, col parquetColumn) interface{} {
+	switch v := val.(type) {
+	case []byte:
+		if col.typ == parquetByteArray && (col.converted == parquetUTF8 || col.converted == parquetEnum ||
+			col.converted == parquetJSON || col.logical.has(1) || col.logical.has(4) || col.logical.has(12)) {
+			return string(v)
+		}
+	case int32:
+		if col.converted == parquetDate || col.logical.has(6) {
+			return time.Unix(int64(v)*86400, 0).UTC()
+		}
+	case int64:
+		if ts := col.logical.strct(8); ts != nil {
+			unit := ts.strct(2)
+			switch {
+			case unit.has(1):
+				return time.UnixMilli(v).UTC()
+			case unit.has(2):
+				return time.UnixMicro(v).UTC()
+			case unit.has(3):
+				return time.Unix(0, v).UTC()
+			}
+		}
+		switch col.converted {
+		case parquetTimestampMillis:
+			return time.UnixMilli(v).UTC()
+		case parquetTimestampMicros:
+			return time.UnixMicro(v).UTC()
+		}
+	}
+	return val
+}
+
+// decodeHybrid decodes n values of the RLE/bit-packing hybrid encoding
+func decodeHybrid(data []byte, bitWidth int, n int) ([]int, error) {
+	if bitWidth > 32 {
+		return nil, errors.New("Invalid bit width")
+	}
+	out := make([]int, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	pos := 0
+	for len(out) < n {
+		header, size := binary.Uvarint(data[pos:])
+		if size <= 0 {
+			return nil, errors.New("Invalid hybrid encoded data")
+		}
+		pos += size
+		if header&1 == 0 { // rle run
+			count := int(header >> 1)
+			if pos+byteWidth > len(data) {
+				return nil, errors.New("Invalid hybrid encoded data")
+			}
+			var v int
+			for b := 0; b < byteWidth; b++ {
+				v |= int(data[pos+b]) << (8 * b)
+			}
+			pos += byteWidth
+			for i := 0; i < count && len(out) < n; i++ {
+				out = append(out, v)
+			}
+		} else { // bit packed groups of 8
+			if header>>1 > uint64(len(data)) {
+				return nil, errors.New("Invalid hybrid encoded data")
+			}
+			count := int(header>>1) * 8
+			if count*bitWidth/8 > len(data)-pos {
+				return nil, errors.New("Invalid hybrid encoded data")
+			}
+			for i := 0; i < count; i++ {
+				var v int
+				for b := 0; b < bitWidth; b++ {
+					bit := i*bitWidth + b
+					v |= int(data[pos+bit/8]>>(bit%8)&1) << b
+				}
+				if len(out) < n {
+					out = append(out, v)
+				}
+			}
+			pos += count * bitWidth / 8
+		}
+	}
+	return out, nil
+}
+
+// encodeLevels encodes definition levels of bit width 1 as rle runs
+func encodeLevels(levels []int) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf = binary.AppendUvarint(buf, uint64(j-i)<<1)
+		buf = append(buf, byte(levels[i]))
+		i = j
+	}
+	return buf
+}
+
+// WriteParquet writes the table to a .parquet file as a single row group. The index is written as the first column and restored by ReadParquet. Each column takes its parquet type from its first non-missing value: bool, int32 (and smaller ints), int64 (and int), float32, float64, string, []byte and time.Time (as a timestamp in microseconds) are supported and other values are written as strings using DefaultFormats. Columns holding missing values are written as optional
+func WriteParquet(path string, t *Table, codec ParquetCodec) error {
+	header := mergeIndex1D(t.Index.Header, t.Header.Slice)
+	cols := make([][]interface{}, len(header))
+	if len(t.Vals) > 0 {
+		cols = getValsOrient(1, mergeIndex2D(t.Index.Slice, t.Vals))
+	}
+
+	names := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, label := range header {
+		names[i] = DefaultFormats.Format(nil, label)
+		if seen[names[i]] {
+			return fmt.Errorf("Duplicate column name %q", names[i])
+		}
+		seen[names[i]] = true
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := &errWriter{w: file}
+	w.Write(parquetMagic)
+	offset := int64(len(parquetMagic))
+
+	schema := []interface{}{thriftStruct{4: "schema", 5: int32(len(names))}}
+	chunks := make([]interface{}, len(names))
+	var total int64
+	for i, name := range names {
+		el, body, err := parquetEncodeColumn(name, cols[i])
+		if err != nil {
+			file.Close()
+			return err
+		}
+		schema = append(schema, el)
+
+		compressed := body
+		codecID := int32(parquetCodecUncompressed)
+		if codec == ParquetSnappy {
+			compressed = snappy.Encode(nil, body)
+			codecID = parquetCodecSnappy
+		}
+		page := &thriftEncoder{}
+		page.writeStruct(thriftStruct{
+			1: int32(parquetDataPage),
+			2: int32(len(body)),
+			3: int32(len(compressed)),
+			5: thriftStruct{1: int32(len(cols[i])), 2: int32(parquetPlain), 3: int32(parquetRLE), 4: int32(parquetRLE)}})
+		w.Write(page.buf)
+		w.Write(compressed)
+
+		size := int64(len(page.buf) + len(compressed))
+		chunks[i] = thriftStruct{
+			2: offset,
+			3: thriftStruct{
+				1: el[1],
+				2: thriftListOf{thriftI32, []interface{}{int32(parquetPlain), int32(parquetRLE)}},
+				3: thriftListOf{thriftBinary, []interface{}{name}},
+				4: codecID,
+				5: int64(len(cols[i])),
+				6: int64(len(page.buf) + len(body)),
+				7: size,
+				9: offset}}
+		offset += size
+		total += size
+	}
+
+	meta := &thriftEncoder{}
+	err = meta.writeStruct(thriftStruct{
+		1: int32(1),
+		2: thriftListOf{thriftStruc, schema},
+		3: int64(len(t.Vals)),
+		4: thriftListOf{thriftStruc, []interface{}{thriftStruct{
+			1: thriftListOf{thriftStruc, chunks},
+			2: total,
+			3: int64(len(t.Vals))}}},
+		5: thriftListOf{thriftStruc, []interface{}{
+			thriftStruct{1: indexMetadataKey, 2: names[0]},
+			thriftStruct{1: headerMetadataKey, 2: DefaultFormats.Format(nil, t.Header.Header)}}},
+		6: "GoTable"})
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.Write(meta.buf)
+	w.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta.buf))))
+	w.Write(parquetMagic)
+
+	if w.err != nil {
+		file.Close()
+		return w.err
+	}
+	return file.Close()
+}
+
+// parquetEncodeColumn returns the schema element of a column and its uncompressed data page body
+func parquetEncodeColumn(name string, col []interface{}) (thriftStruct, []byte, error) {
+	var sample interface{}
+	optional := false
+	for _, val := range col {
+		if IsNA(val) {
+			optional = true
+		} else if sample == nil {
+			sample = val
+		}
+	}
+
+	el := thriftStruct{4: name, 3: int32(0)}
+	if optional {
+		el[3] = int32(1)
+	}
+	var typ int32
+	switch sample.(type) {
+	case bool:
+		typ = parquetBoolean
+	case int8, int16, int32, uint8, uint16:
+		typ = parquetInt32
+	case int, int64, uint32, time.Duration:
+		typ = parquetInt64
+	case float32:
+		typ = parquetFloat
+	case float64:
+		typ = parquetDouble
+	case []byte:
+		typ = parquetByteArray
+	case time.Time:
+		typ = parquetInt64
+		el[6] = int32(parquetTimestampMicros)
+		el[10] = thriftStruct{8: thriftStruct{1: true, 2: thriftStruct{2: thriftStruct{}}}}
+	default: // strings and values formatted as strings
+		typ = parquetByteArray
+		el[6] = int32(parquetUTF8)
+		el[10] = thriftStruct{1: thriftStruct{}}
+		sample = ""
+	}
+	el[1] = typ
+
+	var body []byte
+	if optional {
+		levels := make([]int, len(col))
+		for i, val := range col {
+			if !IsNA(val) {
+				levels[i] = 1
+			}
+		}
+		encoded := encodeLevels(levels)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(encoded)))
+		body = append(body, encoded...)
+	}
+
+	var bits []bool
+	for _, val := range col {
+		if IsNA(val) {
+			continue
+		}
+		if _, isString := sample.(string); isString {
+			s := DefaultFormats.Format(nil, val)
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(s)))
+			body = append(body, s...)
+			continue
+		}
+		var err error
+		switch typ {
+		case parquetBoolean:
+			b, ok := val.(bool)
+			if !ok {
+				err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+			}
+			bits = append(bits, b)
+		case parquetInt32, parquetInt64:
+			var v int64
+			switch x := val.(type) {
+			case time.Time:
+				if _, ok := sample.(time.Time); ok {
+					v = x.UnixMicro()
+				} else {
+					err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+				}
+			default:
+				if v, err = parquetInt(val); err != nil {
+					err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+				}
+			}
+			if typ == parquetInt32 {
+				if v < math.MinInt32 || v > math.MaxInt32 {
+					err = fmt.Errorf("Column %s: %d does not fit in int32", name, v)
+				}
+				body = binary.LittleEndian.AppendUint32(body, uint32(v))
+			} else {
+				body = binary.LittleEndian.AppendUint64(body, uint64(v))
+			}
+		case parquetFloat:
+			f, ok := val.(float32)
+			if !ok {
+				err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+			}
+			body = binary.LittleEndian.AppendUint32(body, math.Float32bits(f))
+		case parquetDouble:
+			f, ok := val.(float64)
+			if !ok {
+				err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+			}
+			body = binary.LittleEndian.AppendUint64(body, math.Float64bits(f))
+		case parquetByteArray:
+			b, ok := val.([]byte)
+			if !ok {
+				err = fmt.Errorf("Column %s mixes %T and %T", name, sample, val)
+			}
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(b)))
+			body = append(body, b...)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if typ == parquetBoolean {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, b := range bits {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		body = append(body, packed...)
+	}
+	return el, body, nil
+}
+
+// parquetInt converts any Go integer to an int64
+func parquetInt(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case time.Duration:
+		return int64(v), nil
+	}
+	return 0, fmt.Errorf("%T is not an integer", val)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RankMethod chooses the rank given to tied values by Rank
+type RankMethod uint8
+
+const (
+	RankAverage RankMethod = iota // average of the ranks of the group
+	RankMin                       // lowest rank of the group
+	RankMax                       // highest rank of the group
+	RankFirst                     // ranks in order of appearance
+	RankDense                     // lowest rank of the group, with ranks rising by 1 between groups
+)
+
+// Rank returns the ranks, from 1, of the values of a column (by name or position) as a Series indexed like the table. Numbers, times and strings are compared by value and categories of an ordered Categorical by their order. Missing values get NaN and are not counted. If pct is true, ranks are divided by the number of values, or by the highest rank for RankDense
+func (t *Table) Rank(column interface{}, method RankMethod, ascending, pct bool) (Series[float64], error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return Series[float64]{}, err
+	}
+	var present []int // rows which are not missing
+	var keys []interface{}
+	for i, row := range t.Vals {
+		val := row[pos]
+		if cat, ok := val.(*Category); ok {
+			val = cat.value
+			if cat.categorical.ordered {
+				val = cat.code
+			}
+		}
+		if IsNA(val) {
+			continue
+		}
+		if len(keys) > 0 {
+			if _, ok := compareValues(keys[0], val); !ok {
+				return Series[float64]{}, fmt.Errorf("Column %v: cannot compare %v and %v", t.Header.Slice[pos], keys[0], val)
+			}
+		}
+		present = append(present, i)
+		keys = append(keys, val)
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		cmp, _ := compareValues(keys[order[a]], keys[order[b]])
+		if ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+	ranks := rankSorted(order, func(a, b int) bool {
+		cmp, _ := compareValues(keys[a], keys[b])
+		return cmp == 0
+	}, method)
+
+	values := make([]float64, len(t.Vals))
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	var total float64
+	for k, i := range present {
+		values[i] = ranks[k]
+		total = math.Max(total, ranks[k])
+	}
+	if method != RankDense {
+		total = float64(len(ranks))
+	}
+	if pct {
+		for _, i := range present {
+			values[i] /= total
+		}
+	}
+	return Series[float64]{Name: t.Header.Slice[pos], Index: t.Index.Copy(), Values: values}, nil
+}
+
+// rankSorted ranks positions 0 to len(order)-1 given them in sorted order, where equal reports whether the values at two positions are tied
+func rankSorted(order []int, equal func(a, b int) bool, method RankMethod) []float64 {
+	ranks := make([]float64, len(order))
+	dense := 0
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && equal(order[start], order[end]) {
+			end++
+		}
+		dense++
+		for k := start; k < end; k++ {
+			var rank float64
+			switch method {
+			case RankMin:
+				rank = float64(start + 1)
+			case RankMax:
+				rank = float64(end)
+			case RankFirst:
+				rank = float64(k + 1)
+			case RankDense:
+				rank = float64(dense)
+			default:
+				rank = float64(start+1+end) / 2
+			}
+			ranks[order[k]] = rank
+		}
+		start = end
+	}
+	return ranks
+}
+
+// Cut returns a copy of the table with a numeric column (by name or position) replaced by the interval of bins each value falls in, as an ordered categorical column. Bins are increasing edges and intervals include their right edge, the first one also its left edge. Labels name the len(bins)-1 intervals, and default to "[a, b]" for the first and "(a, b]" for the others. Missing values and values outside the bins become nil
+func (t *Table) Cut(column interface{}, bins []float64, labels []interface{}) (*Table, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.floatColumn(pos); err != nil {
+		return nil, err
+	}
+	return t.cut(pos, bins, labels)
+}
+
+// QCut returns a copy of the table with a numeric column (by name or position) replaced by its q quantile of the non-missing values, as an ordered categorical column built by Cut. Quantiles interpolate linearly between values, and an error is returned if some are equal. Labels are as in Cut
+func (t *Table) QCut(column interface{}, q int, labels []interface{}) (*Table, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return nil, err
+	}
+	if q < 1 {
+		return nil, errors.New("Number of quantiles must be at least 1")
+	}
+	col, err := t.floatColumn(pos)
+	if err != nil {
+		return nil, err
+	}
+	var sorted []float64
+	for _, f := range col {
+		if !math.IsNaN(f) {
+			sorted = append(sorted, f)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, fmt.Errorf("Column %v has no values", t.Header.Slice[pos])
+	}
+	sort.Float64s(sorted)
+
+	bins := make([]float64, q+1)
+	for k := range bins {
+		lo, rem := k*(len(sorted)-1)/q, k*(len(sorted)-1)%q // quantile k/q is at position lo + rem/q
+		bins[k] = sorted[lo]
+		if rem > 0 {
+			bins[k] += (sorted[lo+1] - sorted[lo]) * float64(rem) / float64(q)
+		}
+		if k > 0 && bins[k] == bins[k-1] {
+			return nil, fmt.Errorf("Quantile edge %v is not unique", DefaultFormats.Format(nil, bins[k]))
+		}
+	}
+	return t.cut(pos, bins, labels)
+}
+
+// floatColumn returns the values of the column at pos as floats, NaN for missing values, or an error naming the first value which is not a number
+func (t *Table) floatColumn(pos int) ([]float64, error) {
+	col := make([]float64, len(t.Vals))
+	for i, row := range t.Vals {
+		if IsNA(row[pos]) {
+			col[i] = math.NaN()
+			continue
+		}
+		f, ok := toFloat(row[pos])
+		if _, isBool := row[pos].(bool); !ok || isBool {
+			return nil, fmt.Errorf("Row %v: %v is not a number", t.Index.Slice[i], row[pos])
+		}
+		col[i] = f
+	}
+	return col, nil
+}
+
+// cut is Cut for the numeric column at pos
+func (t *Table) cut(pos int, bins []float64, labels []interface{}) (*Table, error) {
+	if len(bins) < 2 {
+		return nil, errors.New("Bins must have at least 2 edges")
+	}
+	for k := 1; k < len(bins); k++ {
+		if !(bins[k] > bins[k-1]) {
+			return nil, errors.New("Bins must be increasing")
+		}
+	}
+	if labels == nil {
+		labels = make([]interface{}, len(bins)-1)
+		for k := range labels {
+			open := "("
+			if k == 0 {
+				open = "["
+			}
+			labels[k] = fmt.Sprintf("%s%s, %s]", open, DefaultFormats.Format(nil, bins[k]), DefaultFormats.Format(nil, bins[k+1]))
+		}
+	} else if len(labels) != len(bins)-1 {
+		return nil, fmt.Errorf("Got %d labels for %d bins", len(labels), len(bins)-1)
+	}
+	c, err := NewCategorical(labels, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.recode(pos, c, func(val interface{}) interface{} {
+		f, _ := toFloat(val)
+		if IsNA(val) || f < bins[0] || f > bins[len(bins)-1] {
+			return nil
+		}
+		k := sort.SearchFloat64s(bins, f) // first edge >= f
+		if k == 0 {
+			k = 1
+		}
+		return c.categories[k-1]
+	}), nil
+}
+
+// String returns the name of the method
+func (m RankMethod) String() string {
+	switch m {
+	case RankAverage:
+		return "average"
+	case RankMin:
+		return "min"
+	case RankMax:
+		return "max"
+	case RankFirst:
+		return "first"
+	case RankDense:
+		return "dense"
+	}
+	return fmt.Sprintf("RankMethod(%d)", uint8(m))
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// RenderFormat selects the output format of Render
+type RenderFormat uint8
+
+const (
+	RenderASCII    RenderFormat = iota // box drawn with ascii characters, as printed by PrintTable
+	RenderMarkdown                     // GitHub flavored Markdown table
+	RenderHTML                         // HTML <table>
+	RenderLaTeX                        // LaTeX tabular environment
+	RenderPlain                        // columns aligned with spaces and no borders
+)
+
+// Align is the horizontal alignment of a column
+type Align uint8
+
+const (
+	AlignDefault Align = iota // numeric columns are aligned right and all others left
+	AlignLeft
+	AlignRight
+	AlignCenter
+)
+
+// RenderOptions configures Render. The zero value renders an ascii table like PrintTable
+type RenderOptions struct {
+	Format    RenderFormat
+	Align     map[interface{}]Align // alignment by column name. The index column is found under Table.Index.Header
+	MaxWidth  int                   // cells longer than MaxWidth characters are truncated. 0 means no limit
+	Precision int                   // digits after the decimal point for floats without a column Formatter. 0 keeps the shortest representation
+	HideIndex bool                  // leave the index out of the output
+	Formats   *FormatRegistry       // Formatters used for cells. nil means DefaultFormats
+}
+
+// Render writes the table to w in the format described by opts
+func (t *Table) Render(w io.Writer, opts RenderOptions) error {
+	fr := opts.Formats
+	if fr == nil {
+		fr = DefaultFormats
+	}
+
+	header := t.Header.Slice
+	vals := t.Vals
+	if !opts.HideIndex {
+		header = mergeIndex1D(t.Index.Header, t.Header.Slice)
+		vals = mergeIndex2D(t.Index.Slice, t.Vals)
+	}
+
+	names := fr.formatSlice(nil, header)
+	rows := make([][]string, len(vals))
+	for i, row := range vals {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = fr.formatPrecision(header[j], cell, opts.Precision)
+		}
+	}
+	if opts.MaxWidth > 0 {
+		truncate(names, opts.MaxWidth)
+		for _, row := range rows {
+			truncate(row, opts.MaxWidth)
+		}
+	}
+
+	aligns := make([]Align, len(header))
+	for j, name := range header {
+		aligns[j] = opts.Align[name]
+		if aligns[j] == AlignDefault {
+			aligns[j] = AlignLeft
+			if isNumericColumn(rows, j) {
+				aligns[j] = AlignRight
+			}
+		}
+	}
+
+	ew := &errWriter{w: w}
+	switch opts.Format {
+	case RenderASCII:
+		renderASCII(ew, names, rows, aligns, opts.MaxWidth > 0)
+	case RenderMarkdown:
+		renderMarkdown(ew, names, rows, aligns)
+	case RenderHTML:
+		renderHTML(ew, names, rows, aligns, !opts.HideIndex)
+	case RenderLaTeX:
+		renderLaTeX(ew, names, rows, aligns)
+	case RenderPlain:
+		renderPlain(ew, names, rows, aligns)
+	default:
+		return errors.New("Unknown render format")
+	}
+	return ew.err
+}
+
+// formatPrecision is the same as Format but rounds floats to precision digits unless a column Formatter is registered
+func (fr *FormatRegistry) formatPrecision(column interface{}, val interface{}, precision int) string {
+	if precision > 0 {
+		fr.mu.RLock()
+		_, ok := fr.columns[column]
+		fr.mu.RUnlock()
+		if !ok {
+			switch v := val.(type) {
+			case float64:
+				if !IsNA(v) {
+					return strconv.FormatFloat(v, 'f', precision, 64)
+				}
+			case float32:
+				if !IsNA(v) {
+					return strconv.FormatFloat(float64(v), 'f', precision, 32)
+				}
+			}
+		}
+	}
+	return fr.Format(column, val)
+}
+
+// truncate shortens every string longer than width characters in place, marking the cut with an ellipsis
+func truncate(slice []string, width int) {
+	for i, s := range slice {
+		if utf8.RuneCountInString(s) > width {
+			slice[i] = string([]rune(s)[:width-1]) + "…"
+		}
+	}
+}
+
+// isNumericColumn reports whether every non-empty cell of column j parses as a number
+func isNumericColumn(rows [][]string, j int) bool {
+	var found bool
+	for _, row := range rows {
+		if row[j] == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(row[j], 64); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// errWriter remembers the first error returned by w and skips all writes after it
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	ew.err = err
+	return n, err
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(ew, format, args...)
+}
+
+func renderASCII(w io.Writer, names []string, rows [][]string, aligns []Align, noWrap bool) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(names)
+	table.AppendBulk(rows)
+	keys := make([]int, len(aligns))
+	for j, align := range aligns {
+		switch align {
+		case AlignLeft:
+			keys[j] = tablewriter.ALIGN_LEFT
+		case AlignRight:
+			keys[j] = tablewriter.ALIGN_RIGHT
+		case AlignCenter:
+			keys[j] = tablewriter.ALIGN_CENTER
+		}
+	}
+	table.SetColumnAlignment(keys)
+	if noWrap {
+		table.SetAutoWrapText(false)
+	}
+	table.Render()
+}
+
+func renderMarkdown(w *errWriter, names []string, rows [][]string, aligns []Align) {
+	escape := func(s string) string {
+		return strings.ReplaceAll(s, "|", `\|`)
+	}
+	widths := columnWidths(names, rows, escape)
+	for j := range widths {
+		if widths[j] < 3 {
+			widths[j] = 3
+		}
+	}
+
+	writeRow := func(cells []string) {
+		for j, cell := range cells {
+			w.printf("| %s ", pad(escape(cell), widths[j], aligns[j]))
+		}
+		w.printf("|\n")
+	}
+
+	writeRow(names)
+	for j, align := range aligns {
+		dashes := strings.Repeat("-", widths[j])
+		switch align {
+		case AlignLeft:
+			dashes = ":" + dashes[1:]
+		case AlignRight:
+			dashes = dashes[1:] + ":"
+		case AlignCenter:
+			dashes = ":" + dashes[2:] + ":"
+		}
+		w.printf("| %s ", dashes)
+	}
+	w.printf("|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
+
+func renderHTML(w *errWriter, names []string, rows [][]string, aligns []Align, index bool) {
+	style := func(j int) string {
+		switch aligns[j] {
+		case AlignRight:
+			return ` style="text-align: right;"`
+		case AlignCenter:
+			return ` style="text-align: center;"`
+		}
+		return ""
+	}
+
+	w.printf("<table>\n  <thead>\n    <tr>\n")
+	for j, name := range names {
+		w.printf("      <th%s>%s</th>\n", style(j), html.EscapeString(name))
+	}
+	w.printf("    </tr>\n  </thead>\n  <tbody>\n")
+	for _, row := range rows {
+		w.printf("    <tr>\n")
+		for j, cell := range row {
+			tag := "td"
+			if index && j == 0 {
+				tag = "th"
+			}
+			w.printf("      <%s%s>%s</%s>\n", tag, style(j), html.EscapeString(cell), tag)
+		}
+		w.printf("    </tr>\n")
+	}
+	w.printf("  </tbody>\n</table>\n")
+}
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+	`{`, `\{`, `}`, `\}`, `~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`)
+
+func renderLaTeX(w *errWriter, names []string, rows [][]string, aligns []Align) {
+	spec := make([]byte, len(aligns))
+	for j, align := range aligns {
+		switch align {
+		case AlignRight:
+			spec[j] = 'r'
+		case AlignCenter:
+			spec[j] = 'c'
+		default:
+			spec[j] = 'l'
+		}
+	}
+
+	writeRow := func(cells []string) {
+		escaped := make([]string, len(cells))
+		for j, cell := range cells {
+			escaped[j] = latexEscaper.Replace(cell)
+		}
+		w.printf("%s \\\\\n", strings.Join(escaped, " & "))
+	}
+
+	w.printf("\\begin{tabular}{%s}\n\\hline\n", spec)
+	writeRow(names)
+	w.printf("\\hline\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	w.printf("\\hline\n\\end{tabular}\n")
+}
+
+func renderPlain(w *errWriter, names []string, rows [][]string, aligns []Align) {
+	widths := columnWidths(names, rows, nil)
+	writeRow := func(cells []string) {
+		padded := make([]string, len(cells))
+		for j, cell := range cells {
+			padded[j] = pad(cell, widths[j], aligns[j])
+		}
+		w.printf("%s\n", strings.TrimRight(strings.Join(padded, "  "), " "))
+	}
+
+	writeRow(names)
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
+
+// columnWidths returns the widest cell of every column in characters, after applying escape if it is not nil
+func columnWidths(names []string, rows [][]string, escape func(string) string) []int {
+	widths := make([]int, len(names))
+	measure := func(cells []string) {
+		for j, cell := range cells {
+			if escape != nil {
+				cell = escape(cell)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+	measure(names)
+	for _, row := range rows {
+		measure(row)
+	}
+	return widths
+}
+
+// pad fills s with spaces up to width characters according to align
+func pad(s string, width int, align Align) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", n) + s
+	case AlignCenter:
+		return strings.Repeat(" ", n/2) + s + strings.Repeat(" ", n-n/2)
+	}
+	return s + strings.Repeat(" ", n)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IfExists decides what ToSQL does when the target table already exists
+type IfExists uint8
+
+const (
+	IfExistsFail    IfExists = iota // return an error
+	IfExistsReplace                 // drop the table and create it again
+	IfExistsAppend                  // insert the rows into the existing table
+)
+
+// SQLDialect is the flavour of SQL ToSQL writes: its placeholders, identifier quoting and column types
+type SQLDialect uint8
+
+const (
+	SQLite    SQLDialect = iota // ? placeholders
+	Postgres                    // $1 placeholders
+	MySQL                       // ? placeholders and `quoted` identifiers
+	SQLServer                   // @p1 placeholders. Statements are limited to 2100 parameters, so batchSize times the number of columns must stay below it
+)
+
+// FromSQL runs a query and creates a Table from its rows with a sequential index. Column names become the header and values keep the type reported by the driver, with []byte converted to the column's scan type unless the column is binary. NULL values become nil
+func FromSQL(db *sql.DB, query string, args ...interface{}) (*Table, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var vals [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(names))
+		dest := make([]interface{}, len(names))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, val := range row {
+			if row[i], err = fromSQLValue(val, types[i]); err != nil {
+				return nil, fmt.Errorf("Column %s: %v", names[i], err)
+			}
+		}
+		vals = append(vals, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(names))
+	for i, name := range names {
+		header[i] = name
+	}
+	return &Table{
+		Header: CreateGenMS(1, header),
+		Index:  CreateNumMS(0, len(vals)),
+		Vals:   vals}, nil
+}
+
+// fromSQLValue converts the bytes some drivers return for every column to the scan type of the column
+func fromSQLValue(val interface{}, typ *sql.ColumnType) (interface{}, error) {
+	b, ok := val.([]byte)
+	if !ok {
+		return val, nil
+	}
+	name := strings.ToUpper(typ.DatabaseTypeName())
+	if strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || strings.Contains(name, "BYTEA") {
+		return append([]byte(nil), b...), nil
+	}
+	s := string(b)
+	scanType := typ.ScanType()
+	if scanType == nil {
+		return s, nil
+	}
+	if scanType.Kind() == reflect.Struct && scanType.NumField() > 0 { // sql.NullInt64 and friends
+		scanType = scanType.Field(0).Type
+	}
+	switch scanType.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(s, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+// ToSQL writes the table to the database table tableName, creating it when needed, using the SQL of dialect. The index is written as the first column, named after Index.Header, so it can be restored with SetIndex after FromSQL. Column types are taken from the first non-missing value of each column and missing values are written as NULL. Rows are inserted in a single transaction using multi-row inserts of batchSize rows (100 if batchSize < 1)
+func (t *Table) ToSQL(db *sql.DB, dialect SQLDialect, tableName string, ifExists IfExists, batchSize int) error {
+	if dialect > SQLServer {
+		return errors.New("Invalid SQLDialect value")
+	}
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	header := mergeIndex1D(t.Index.Header, t.Header.Slice)
+	names := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, label := range header {
+		names[i] = DefaultFormats.Format(nil, label)
+		if seen[names[i]] {
+			return fmt.Errorf("Duplicate column name %s", names[i])
+		}
+		seen[names[i]] = true
+	}
+	rows := mergeIndex2D(t.Index.Slice, t.Vals)
+
+	types := make([]string, len(names))
+	for j := range types {
+		types[j] = dialect.columnType(nil)
+		for _, row := range rows {
+			if !IsNA(row[j]) {
+				types[j] = dialect.columnType(row[j])
+				break
+			}
+		}
+	}
+
+	// checked outside the transaction since a failed statement aborts transactions on some databases
+	var count int
+	if err := db.QueryRow(dialect.existsQuery(), tableName).Scan(&count); err != nil {
+		return err
+	}
+	exists := count > 0
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	quoted := dialect.quote(tableName)
+	if exists {
+		switch ifExists {
+		case IfExistsFail:
+			return fmt.Errorf("Table %s already exists", tableName)
+		case IfExistsReplace:
+			if _, err := tx.Exec("DROP TABLE " + quoted); err != nil {
+				return err
+			}
+			exists = false
+		case IfExistsAppend:
+		default:
+			return errors.New("Invalid IfExists value")
+		}
+	}
+	if !exists {
+		cols := make([]string, len(names))
+		for j, name := range names {
+			cols[j] = dialect.quote(name) + " " + types[j]
+		}
+		if _, err := tx.Exec("CREATE TABLE " + quoted + " (" + strings.Join(cols, ", ") + ")"); err != nil {
+			return err
+		}
+	}
+
+	cols := make([]string, len(names))
+	for j, name := range names {
+		cols[j] = dialect.quote(name)
+	}
+	insert := "INSERT INTO " + quoted + " (" + strings.Join(cols, ", ") + ") VALUES "
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*len(names))
+		for _, row := range rows[start:end] {
+			placeholders := make([]string, len(row))
+			for j, val := range row {
+				args = append(args, toSQLValue(val))
+				placeholders[j] = dialect.placeholder(len(args))
+			}
+			values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		}
+		if _, err := tx.Exec(insert+strings.Join(values, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// placeholder returns the placeholder of the nth argument of a statement, counting from 1
+func (d SQLDialect) placeholder(n int) string {
+	switch d {
+	case Postgres:
+		return "$" + strconv.Itoa(n)
+	case SQLServer:
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// quote quotes an identifier
+func (d SQLDialect) quote(name string) string {
+	if d == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// existsQuery returns a query counting the tables named by its single argument, looked up in the catalog so that errors are not mistaken for a missing table
+func (d SQLDialect) existsQuery() string {
+	switch d {
+	case Postgres:
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	case MySQL:
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	case SQLServer:
+		return "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = @p1"
+	}
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+}
+
+// columnType returns the column type used by ToSQL for a value, nil for a column without values
+func (d SQLDialect) columnType(val interface{}) string {
+	var types [5]string // bool, float, bytes, time, text
+	switch d {
+	case Postgres:
+		types = [5]string{"BOOLEAN", "DOUBLE PRECISION", "BYTEA", "TIMESTAMPTZ", "TEXT"}
+	case MySQL:
+		types = [5]string{"BOOLEAN", "DOUBLE", "LONGBLOB", "DATETIME(6)", "LONGTEXT"}
+	case SQLServer:
+		types = [5]string{"BIT", "FLOAT", "VARBINARY(MAX)", "DATETIMEOFFSET", "NVARCHAR(MAX)"}
+	default:
+		types = [5]string{"BOOLEAN", "DOUBLE PRECISION", "BLOB", "TIMESTAMP", "TEXT"}
+	}
+	switch val.(type) {
+	case bool:
+		return types[0]
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, time.Duration:
+		return "BIGINT"
+	case float32, float64:
+		return types[1]
+	case []byte:
+		return types[2]
+	case time.Time:
+		return types[3]
+	}
+	return types[4]
+}
+
+// toSQLValue converts a value to a type every driver accepts
+func toSQLValue(val interface{}) interface{} {
+	if IsNA(val) {
+		return nil
+	}
+	switch v := val.(type) {
+	case bool, int64, float64, string, []byte, time.Time:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return DefaultFormats.Format(nil, v)
+		}
+		return int64(v)
+	case time.Duration:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return DefaultFormats.Format(nil, val)
+}
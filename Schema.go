@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of value held by a column
+type Kind uint8
+
+const (
+	KindAny      Kind = iota // any value, used by Schema to skip the kind check
+	KindBool                 // bool
+	KindInt                  // int, or any integer type when validating
+	KindFloat                // float64, or float32 when validating
+	KindString               // string
+	KindTime                 // time.Time
+	KindDuration             // time.Duration
+)
+
+var kindNames = map[Kind]string{
+	KindAny:      "any",
+	KindBool:     "bool",
+	KindInt:      "int",
+	KindFloat:    "float",
+	KindString:   "string",
+	KindTime:     "time",
+	KindDuration: "duration",
+}
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", uint8(k))
+}
+
+// kindOf returns the kind of a non-missing value, KindAny if it has none of the other kinds
+func kindOf(val interface{}) Kind {
+	switch val.(type) {
+	case bool:
+		return KindBool
+	case time.Duration:
+		return KindDuration
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return KindInt
+	case float32, float64:
+		return KindFloat
+	case string:
+		return KindString
+	case time.Time:
+		return KindTime
+	}
+	return KindAny
+}
+
+// convertKind converts a value to the Go type of kind. Strings are parsed, times with DatetimeLayouts in UTC, and numbers are converted between ints and floats, failing if a fractional part would be dropped or the value overflows. Missing values stay nil and empty strings become nil unless kind is KindString
+func convertKind(val interface{}, kind Kind) (interface{}, error) {
+	if cat, ok := val.(*Category); ok {
+		val = cat.value
+	}
+	if IsNA(val) {
+		return nil, nil
+	}
+	if s, ok := val.(string); ok && kind != KindString && strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var typ reflect.Type
+	switch kind {
+	case KindAny:
+		return val, nil
+	case KindString:
+		return DefaultFormats.Format(nil, val), nil
+	case KindBool:
+		typ = reflect.TypeOf(false)
+	case KindInt:
+		typ = reflect.TypeOf(0)
+	case KindFloat:
+		typ = reflect.TypeOf(0.0)
+	case KindDuration:
+		typ = reflect.TypeOf(time.Duration(0))
+	case KindTime:
+		if s, ok := val.(string); ok {
+			last := 0
+			if tm, ok := parseDatetime(strings.TrimSpace(s), DatetimeLayouts, &last, time.UTC); ok {
+				return tm, nil
+			}
+			return nil, fmt.Errorf("Cannot parse %q as a date", s)
+		}
+		typ = reflect.TypeOf(time.Time{})
+	default:
+		return nil, fmt.Errorf("Invalid kind %v", kind)
+	}
+
+	if s, ok := val.(string); ok {
+		val = strings.TrimSpace(s)
+	}
+	out := reflect.New(typ).Elem()
+	if err := setField(out, val); err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+// AsType returns a copy of the table with columns (by name or position) converted to the given kinds. If strict is true, an error listing every value which could not be converted is returned, otherwise those values become nil
+func (t *Table) AsType(kinds map[interface{}]Kind, strict bool) (*Table, error) {
+	positions := make(map[int]Kind, len(kinds))
+	for column, kind := range kinds {
+		pos, err := t.lookupCol(column)
+		if err != nil {
+			return nil, fmt.Errorf("Column %v: %v", column, err)
+		}
+		positions[pos] = kind
+	}
+
+	t0 := t.Copy(true)
+	var errs []error
+	for i, row := range t0.Vals {
+		for j := range row {
+			kind, ok := positions[j]
+			if !ok {
+				continue
+			}
+			val, err := convertKind(row[j], kind)
+			if err != nil {
+				if strict {
+					errs = append(errs, fmt.Errorf("Row %v, column %v: %v", t.Index.Slice[i], t.Header.Slice[j], err))
+				}
+				val = nil
+			}
+			row[j] = val
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	for pos := range positions {
+		delete(t0.categoricals, t.Header.Slice[pos]) // converted columns are no longer categorical
+	}
+	return t0, nil
+}
+
+// ColumnSchema describes the values allowed in one column
+type ColumnSchema struct {
+	Name     interface{}
+	Kind     Kind          // KindAny allows values of any kind
+	Nullable bool          // whether missing values are allowed
+	Allowed  []interface{} // if not nil, the only values allowed
+	Min, Max interface{}   // inclusive bounds of numbers or times, nil for none
+}
+
+// Schema describes the columns of a table. If Strict is true, columns which are not in the schema are violations
+type Schema struct {
+	Columns []ColumnSchema
+	Strict  bool
+}
+
+// Violation is a value, or a column when Row is nil, which does not follow a Schema
+type Violation struct {
+	Row     interface{}
+	Column  interface{}
+	Value   interface{}
+	Message string
+}
+
+// String describes the violation
+func (v Violation) String() string {
+	if v.Row == nil {
+		return fmt.Sprintf("Column %v: %s", v.Column, v.Message)
+	}
+	return fmt.Sprintf("Row %v, column %v: %s", v.Row, v.Column, v.Message)
+}
+
+// Validate checks the table against a schema and returns every violation, in column then row order. It returns nil if the table follows the schema
+func (t *Table) Validate(schema Schema) []Violation {
+	var violations []Violation
+	inSchema := make(map[interface{}]bool, len(schema.Columns))
+	for _, col := range schema.Columns {
+		inSchema[col.Name] = true
+		indices, ok := t.Header.Map[col.Name]
+		if !ok {
+			violations = append(violations, Violation{Column: col.Name, Message: "missing column"})
+			continue
+		}
+		allowed := make(map[interface{}]bool, len(col.Allowed))
+		for _, val := range col.Allowed {
+			allowed[val] = true
+		}
+		for _, j := range indices {
+			for i, row := range t.Vals {
+				if msg := col.check(row[j], allowed); msg != "" {
+					violations = append(violations, Violation{t.Index.Slice[i], col.Name, row[j], msg})
+				}
+			}
+		}
+	}
+	if schema.Strict {
+		for _, name := range getuniqs(t.Header.Slice) {
+			if !inSchema[name] {
+				violations = append(violations, Violation{Column: name, Message: "column not in schema"})
+			}
+		}
+	}
+	return violations
+}
+
+// check returns why a value breaks the column schema, or an empty string if it does not
+func (col ColumnSchema) check(val interface{}, allowed map[interface{}]bool) string {
+	if cat, ok := val.(*Category); ok {
+		val = cat.value
+	}
+	if IsNA(val) {
+		if !col.Nullable {
+			return "missing value"
+		}
+		return ""
+	}
+	if col.Kind != KindAny && kindOf(val) != col.Kind {
+		return fmt.Sprintf("%T is not %v", val, col.Kind)
+	}
+	if col.Allowed != nil && !allowed[val] {
+		return "value not allowed"
+	}
+	if col.Min != nil {
+		if cmp, ok := compareValues(val, col.Min); !ok || cmp < 0 {
+			return fmt.Sprintf("below minimum %v", col.Min)
+		}
+	}
+	if col.Max != nil {
+		if cmp, ok := compareValues(val, col.Max); !ok || cmp > 0 {
+			return fmt.Sprintf("above maximum %v", col.Max)
+		}
+	}
+	return ""
+}
+
+// compareValues compares two numbers, times or strings, returning false if they cannot be compared
+func compareValues(a, b interface{}) (int, bool) {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ta.Compare(tb), ok
+	}
+	if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		return strings.Compare(sa, sb), ok
+	}
+	fa, ok1 := toFloat(a)
+	fb, ok2 := toFloat(b)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	switch {
+	case fa < fb:
+		return -1, true
+	case fa > fb:
+		return 1, true
+	}
+	return 0, true
+}
+
+// toFloat converts any Go number to a float64
+func toFloat(val interface{}) (float64, bool) {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Series is a typed, labeled column of values. It is the statically typed counterpart of a single Table column
+type Series[T any] struct {
+	Name   interface{}
+	Index  MappedSlice
+	Values []T
+}
+
+// NewSeries creates a Series from values. If index is nil, a sequential index is created
+func NewSeries[T any](name interface{}, values []T, index []interface{}) Series[T] {
+	s := Series[T]{Name: name, Values: values}
+	if index == nil {
+		s.Index = CreateNumMS(0, len(values))
+	} else {
+		s.Index = CreateMS(index, "Index")
+	}
+	return s
+}
+
+// Col returns a column (by name or position) of the table as a Series of type T. An error is returned if any cell, including a missing one, is not of type T
+func Col[T any](t *Table, column interface{}) (Series[T], error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return Series[T]{}, err
+	}
+
+	values := make([]T, len(t.Vals))
+	for i, row := range t.Vals {
+		val, ok := row[pos].(T)
+		if !ok {
+			return Series[T]{}, fmt.Errorf("Cell %v of column %v is %T, not %T", t.Index.Slice[i], t.Header.Slice[pos], row[pos], val)
+		}
+		values[i] = val
+	}
+
+	return Series[T]{Name: t.Header.Slice[pos], Index: t.Index.Copy(), Values: values}, nil
+}
+
+// Len returns the number of values in the Series
+func (s Series[T]) Len() int {
+	return len(s.Values)
+}
+
+// Get returns the values found under an index label
+func (s Series[T]) Get(label interface{}) []T {
+	indices := s.Index.Map[label]
+	out := make([]T, len(indices))
+	for i, index := range indices {
+		out[i] = s.Values[index]
+	}
+	return out
+}
+
+// Interface returns the values of the Series as a slice of interfaces
+func (s Series[T]) Interface() []interface{} {
+	out := make([]interface{}, len(s.Values))
+	for i, val := range s.Values {
+		out[i] = val
+	}
+	return out
+}
+
+// ToTable converts the Series to a single column Table
+func (s Series[T]) ToTable() *Table {
+	vals := make([][]interface{}, len(s.Values))
+	for i, val := range s.Values {
+		vals[i] = []interface{}{val}
+	}
+	return &Table{
+		Header: CreateGenMS(1, []interface{}{s.Name}),
+		Index:  s.Index.Copy(),
+		Vals:   vals}
+}
+
+// MapSeries returns a new Series with f called on every value
+func MapSeries[T any, U any](s Series[T], f func(T) U) Series[U] {
+	values := make([]U, len(s.Values))
+	for i, val := range s.Values {
+		values[i] = f(val)
+	}
+	return Series[U]{Name: s.Name, Index: s.Index.Copy(), Values: values}
+}
+
+// FromRecords is the typed form of FromStructs for a slice of structs
+func FromRecords[T any](records []T) *Table {
+	t, err := FromStructs(records)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return t
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// StrAccessor holds string methods for a column. Values which are not strings, or categories of strings, are treated as missing and stay nil in every result
+type StrAccessor struct {
+	name   interface{}
+	index  MappedSlice
+	values []interface{}
+}
+
+// Str returns the string methods of the Series
+func (s Series[T]) Str() StrAccessor {
+	return StrAccessor{name: s.Name, index: s.Index, values: s.Interface()}
+}
+
+// Str returns the string methods of a column (by name or position)
+func (t *Table) Str(column interface{}) (StrAccessor, error) {
+	pos, err := t.lookupCol(column)
+	if err != nil {
+		return StrAccessor{}, err
+	}
+	return StrAccessor{name: t.Header.Slice[pos], index: t.Index, values: GetTranspose(t.Vals, pos)}, nil
+}
+
+// str returns a value as a string, or false if it is missing or not a string
+func str(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case *Category:
+		s, ok := v.value.(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// apply builds a Series from f called on every string value
+func (sa StrAccessor) apply(f func(string) interface{}) Series[interface{}] {
+	values := make([]interface{}, len(sa.values))
+	for i, val := range sa.values {
+		if s, ok := str(val); ok {
+			values[i] = f(s)
+		}
+	}
+	return Series[interface{}]{Name: sa.name, Index: sa.index.Copy(), Values: values}
+}
+
+// Lower returns the values in lower case
+func (sa StrAccessor) Lower() Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.ToLower(s) })
+}
+
+// Upper returns the values in upper case
+func (sa StrAccessor) Upper() Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.ToUpper(s) })
+}
+
+// Strip returns the values without leading and trailing white space
+func (sa StrAccessor) Strip() Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.TrimSpace(s) })
+}
+
+// Contains returns whether each value contains substr, as bools
+func (sa StrAccessor) Contains(substr string) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.Contains(s, substr) })
+}
+
+// ContainsRegexp returns whether each value matches re, as bools
+func (sa StrAccessor) ContainsRegexp(re *regexp.Regexp) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return re.MatchString(s) })
+}
+
+// HasPrefix returns whether each value starts with prefix, as bools
+func (sa StrAccessor) HasPrefix(prefix string) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.HasPrefix(s, prefix) })
+}
+
+// HasSuffix returns whether each value ends with suffix, as bools
+func (sa StrAccessor) HasSuffix(suffix string) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.HasSuffix(s, suffix) })
+}
+
+// Replace returns the values with the first n occurrences of old replaced by new, all of them if n < 0
+func (sa StrAccessor) Replace(old, new string, n int) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return strings.Replace(s, old, new, n) })
+}
+
+// ReplaceRegexp returns the values with every match of re replaced by repl, which may refer to groups as in regexp.Regexp.ReplaceAllString
+func (sa StrAccessor) ReplaceRegexp(re *regexp.Regexp, repl string) Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return re.ReplaceAllString(s, repl) })
+}
+
+// Len returns the number of characters of each value, as ints
+func (sa StrAccessor) Len() Series[interface{}] {
+	return sa.apply(func(s string) interface{} { return utf8.RuneCountInString(s) })
+}
+
+// PadSide chooses where Pad adds fill characters
+type PadSide uint8
+
+const (
+	PadLeft PadSide = iota
+	PadRight
+	PadBoth
+)
+
+// Pad returns the values padded with fill to at least width characters. PadBoth puts the extra character on the right when the padding is uneven
+func (sa StrAccessor) Pad(width int, side PadSide, fill rune) Series[interface{}] {
+	return sa.apply(func(s string) interface{} {
+		n := width - utf8.RuneCountInString(s)
+		if n <= 0 {
+			return s
+		}
+		switch side {
+		case PadRight:
+			return s + strings.Repeat(string(fill), n)
+		case PadBoth:
+			return strings.Repeat(string(fill), n/2) + s + strings.Repeat(string(fill), n-n/2)
+		}
+		return strings.Repeat(string(fill), n) + s
+	})
+}
+
+// Split splits every value around sep into at most n parts, all of them if n < 0, and returns them as the columns of a table sharing the index of the accessor. Columns are numbered from 0 and values with fewer parts, or missing values, are filled with nil
+func (sa StrAccessor) Split(sep string, n int) *Table {
+	parts := make([][]string, len(sa.values))
+	var width int
+	for i, val := range sa.values {
+		if s, ok := str(val); ok {
+			parts[i] = strings.SplitN(s, sep, n)
+			if len(parts[i]) > width {
+				width = len(parts[i])
+			}
+		}
+	}
+
+	vals := make([][]interface{}, len(parts))
+	for i, row := range parts {
+		vals[i] = make([]interface{}, width)
+		for j, part := range row {
+			vals[i][j] = part
+		}
+	}
+	return &Table{
+		Header: CreateNumMS(1, width),
+		Index:  sa.index.Copy(),
+		Vals:   vals}
+}
+
+// Extract matches every value against re and returns its capture groups as the columns of a table sharing the index of the accessor. Columns take the names of named groups and are otherwise numbered from 0. Values which do not match, or are missing, give a row of nil
+func (sa StrAccessor) Extract(re *regexp.Regexp) *Table {
+	names := re.SubexpNames()[1:]
+	header := make([]interface{}, len(names))
+	for j, name := range names {
+		header[j] = name
+		if name == "" {
+			header[j] = strconv.Itoa(j)
+		}
+	}
+
+	vals := make([][]interface{}, len(sa.values))
+	for i, val := range sa.values {
+		vals[i] = make([]interface{}, len(names))
+		s, ok := str(val)
+		if !ok {
+			continue
+		}
+		match := re.FindStringSubmatchIndex(s)
+		if match == nil {
+			continue
+		}
+		for j := range names {
+			if start, end := match[2*j+2], match[2*j+3]; start >= 0 {
+				vals[i][j] = s[start:end]
+			}
+		}
+	}
+	return &Table{
+		Header: CreateGenMS(1, header),
+		Index:  sa.index.Copy(),
+		Vals:   vals}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structField describes how a struct field maps onto a Table column
+type structField struct {
+	field int
+	name  string
+	index bool
+}
+
+// structFields returns the exported fields of a struct type along with their column names. Fields are named after the field or their `table:"name"` tag, `table:",index"` marks the field as the index and `table:"-"` skips the field
+func structFields(typ reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tags := strings.Split(field.Tag.Get("table"), ",")
+		if !field.IsExported() || tags[0] == "-" {
+			continue
+		}
+		sf := structField{field: i, name: tags[0]}
+		if sf.name == "" {
+			sf.name = field.Name
+		}
+		for _, tag := range tags[1:] {
+			if tag == "index" {
+				sf.index = true
+			}
+		}
+		fields = append(fields, sf)
+	}
+	return fields
+}
+
+// FromStructs creates a Table from a slice of structs (or pointers to structs). Columns are derived from the exported fields as described by structFields and keep the Go type of their field. If a field is tagged as the index, its values become the index instead of a column
+func FromStructs(slice interface{}) (*Table, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice {
+		return nil, errors.New("FromStructs requires a slice of structs")
+	}
+	typ := v.Type().Elem()
+	ptr := typ.Kind() == reflect.Ptr
+	if ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.New("FromStructs requires a slice of structs")
+	}
+
+	fields := structFields(typ)
+	indexField := -1
+	var header []interface{}
+	for i, sf := range fields {
+		if sf.index {
+			if indexField != -1 {
+				return nil, errors.New("Only one field can be tagged as the index")
+			}
+			indexField = i
+			continue
+		}
+		header = append(header, sf.name)
+	}
+
+	index := make([]interface{}, v.Len())
+	vals := make([][]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		record := v.Index(i)
+		if ptr {
+			if record.IsNil() {
+				return nil, fmt.Errorf("Element %d is a nil pointer", i)
+			}
+			record = record.Elem()
+		}
+		row := make([]interface{}, 0, len(header))
+		for j, sf := range fields {
+			val := record.Field(sf.field).Interface()
+			if j == indexField {
+				index[i] = val
+			} else {
+				row = append(row, val)
+			}
+		}
+		vals[i] = row
+	}
+
+	t := &Table{
+		Header: CreateGenMS(1, header),
+		Index:  CreateNumMS(0, v.Len()),
+		Vals:   vals}
+	if indexField != -1 {
+		t.Index = CreateMS(index, fields[indexField].name)
+	}
+	return t, nil
+}
+
+// ToStructs decodes the rows of the table into out, which must be a pointer to a slice of structs (or pointers to structs). Fields are matched to columns as described by structFields and the index fills the field tagged as the index. Cells are converted to the field type where possible, ie. the string "1" can fill an int field, and numbers are converted only if no value is lost, so 3.7 cannot fill an int field. Missing cells leave the field at its zero value. Fields without a matching column and cells which cannot be converted are reported in the returned error
+func (t *Table) ToStructs(out interface{}) error {
+	ptrValue := reflect.ValueOf(out)
+	if ptrValue.Kind() != reflect.Ptr || ptrValue.Elem().Kind() != reflect.Slice {
+		return errors.New("ToStructs requires a pointer to a slice of structs")
+	}
+	sliceValue := ptrValue.Elem()
+	typ := sliceValue.Type().Elem()
+	ptr := typ.Kind() == reflect.Ptr
+	if ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return errors.New("ToStructs requires a pointer to a slice of structs")
+	}
+
+	var errs []error
+	fields := structFields(typ)
+	cols := make([]int, len(fields))
+	for i, sf := range fields {
+		if sf.index {
+			continue
+		}
+		pos, err := t.lookupCol(sf.name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("No column found for field %s", typ.Field(sf.field).Name))
+			pos = -1
+		}
+		cols[i] = pos
+	}
+
+	records := reflect.MakeSlice(sliceValue.Type(), len(t.Vals), len(t.Vals))
+	for i, row := range t.Vals {
+		record := reflect.New(typ).Elem()
+		for j, sf := range fields {
+			var val interface{}
+			if sf.index {
+				val = t.Index.Slice[i]
+			} else if cols[j] == -1 {
+				continue
+			} else {
+				val = row[cols[j]]
+			}
+			if err := setField(record.Field(sf.field), val); err != nil {
+				errs = append(errs, fmt.Errorf("Row %v, column %v: %v", t.Index.Slice[i], sf.name, err))
+			}
+		}
+		if ptr {
+			records.Index(i).Set(record.Addr())
+		} else {
+			records.Index(i).Set(record)
+		}
+	}
+	sliceValue.Set(records)
+
+	return errors.Join(errs...)
+}
+
+// setField assigns a cell to a struct field, converting between strings, numbers, bools and times where needed
+func setField(field reflect.Value, val interface{}) error {
+	if IsNA(val) {
+		return nil
+	}
+	v := reflect.ValueOf(val)
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	if s, ok := val.(string); ok {
+		return setFieldString(field, s)
+	}
+	if field.Kind() == reflect.String {
+		field.SetString(fmt.Sprint(val))
+		return nil
+	}
+	if v.CanConvert(field.Type()) && isNumeric(v.Kind()) && isNumeric(field.Kind()) {
+		out := v.Convert(field.Type())
+		if err := checkConversion(v, out); err != nil {
+			return err
+		}
+		field.Set(out)
+		return nil
+	}
+	return fmt.Errorf("Cannot convert %T to %v", val, field.Type())
+}
+
+// checkConversion returns an error if converting the number in to out lost its value, ie. dropped a fractional part, overflowed or changed sign. Conversions between floats may round and only fail if they overflow
+func checkConversion(in, out reflect.Value) error {
+	f, _ := toFloat(in.Interface())
+	g, _ := toFloat(out.Interface())
+	if isFloat(in.Kind()) && isFloat(out.Kind()) {
+		if math.IsInf(g, 0) && !math.IsInf(f, 0) {
+			return fmt.Errorf("%v overflows %v", in.Interface(), out.Type())
+		}
+		return nil
+	}
+	if out.Convert(in.Type()).Interface() == in.Interface() && (f < 0) == (g < 0) {
+		return nil
+	}
+	switch {
+	case isFloat(in.Kind()) && !math.IsInf(f, 0) && f != math.Trunc(f):
+		return fmt.Errorf("%v has a fractional part and cannot be converted to %v", in.Interface(), out.Type())
+	case isFloat(out.Kind()):
+		return fmt.Errorf("%v cannot be represented exactly by %v", in.Interface(), out.Type())
+	}
+	return fmt.Errorf("%v overflows %v", in.Interface(), out.Type())
+}
+
+// setFieldString parses a string cell into a struct field
+func setFieldString(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err == nil {
+			field.SetBool(b)
+		}
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err == nil {
+				field.SetInt(int64(d))
+			}
+			return err
+		}
+		i, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err == nil {
+			field.SetInt(i)
+		}
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err == nil {
+			field.SetUint(u)
+		}
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err == nil {
+			field.SetFloat(f)
+		}
+		return err
+	}
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			tm, err = time.Parse("2006-01-02", s)
+		}
+		if err == nil {
+			field.Set(reflect.ValueOf(tm))
+		}
+		return err
+	}
+	return fmt.Errorf("Cannot convert string to %v", field.Type())
+}
+
+// isFloat reports whether a kind is a float kind
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isNumeric reports whether a kind is an integer or float kind
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
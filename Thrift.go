@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// This file implements the subset of the Thrift compact protocol needed to read and write Parquet metadata. Structs are decoded into a generic thriftStruct keyed by field id and encoded from a thriftStruct whose values carry their Thrift type in their Go type
+
+// compact protocol type codes
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruc  = 12
+)
+
+// thriftStruct is a Thrift struct keyed by field id. Decoded integers of every width are int64, binaries are []byte, lists are []interface{} and nested structs are thriftStruct. When encoding, int8, int16, int32 and int64 select the integer width, string and []byte are binaries and thriftListOf is a list
+type thriftStruct map[int16]interface{}
+
+// thriftListOf is a list to encode along with the Thrift type of its elements
+type thriftListOf struct {
+	elem  byte
+	items []interface{}
+}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) bool(id int16) bool {
+	v, _ := s[id].(bool)
+	return v
+}
+
+func (s thriftStruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s thriftStruct) strct(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// thriftDecoder reads compact protocol values from a byte slice
+type thriftDecoder struct {
+	buf   []byte
+	pos   int
+	depth int // nesting of the struct being read
+}
+
+// thriftMaxDepth bounds the nesting of structs and containers, so corrupt data cannot exhaust the stack
+const thriftMaxDepth = 64
+
+var errThriftEOF = errors.New("Unexpected end of thrift data")
+
+func (d *thriftDecoder) byte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errThriftEOF
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *thriftDecoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		return 0, errThriftEOF
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *thriftDecoder) varint() (int64, error) {
+	v, err := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *thriftDecoder) readStruct() (thriftStruct, error) {
+	s := make(thriftStruct)
+	var id int16
+	for {
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 { // stop
+			return s, nil
+		}
+		typ := header & 0x0f
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		switch typ {
+		case thriftTrue:
+			s[id] = true
+		case thriftFalse:
+			s[id] = false
+		default:
+			if s[id], err = d.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (d *thriftDecoder) readValue(typ byte) (interface{}, error) {
+	if d.depth >= thriftMaxDepth {
+		return nil, errors.New("Thrift data is nested too deeply")
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	switch typ {
+	case thriftTrue, thriftFalse: // booleans inside lists are encoded as a byte
+		b, err := d.byte()
+		return b == 1, err
+	case thriftByte:
+		b, err := d.byte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return d.varint()
+	case thriftDouble:
+		if d.pos+8 > len(d.buf) {
+			return nil, errThriftEOF
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+		return v, nil
+	case thriftBinary:
+		n, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(d.buf)-d.pos) < n {
+			return nil, errThriftEOF
+		}
+		v := d.buf[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return v, nil
+	case thriftList, thriftSet:
+		header, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = d.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(d.buf)) {
+			return nil, errThriftEOF
+		}
+		items := make([]interface{}, size)
+		for i := range items {
+			if items[i], err = d.readValue(header & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case thriftMap:
+		size, err := d.uvarint()
+		if err != nil || size == 0 {
+			return map[interface{}]interface{}{}, err
+		}
+		types, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[interface{}]interface{})
+		for i := uint64(0); i < size; i++ {
+			k, err := d.readValue(types >> 4)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.readValue(types & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			if b, ok := k.([]byte); ok {
+				k = string(b)
+			}
+			m[k] = v
+		}
+		return m, nil
+	case thriftStruc:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("Unknown thrift type %d", typ)
+}
+
+// thriftEncoder appends compact protocol values to a byte slice
+type thriftEncoder struct {
+	buf []byte
+}
+
+func (e *thriftEncoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *thriftEncoder) varint(v int64) {
+	e.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (e *thriftEncoder) writeStruct(s thriftStruct) error {
+	ids := make([]int, 0, len(s))
+	for id := range s {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	var last int
+	for _, id := range ids {
+		val := s[int16(id)]
+		typ := thriftType(val)
+		if typ == 0 {
+			return fmt.Errorf("Cannot encode %T as thrift", val)
+		}
+		if b, ok := val.(bool); ok && !b {
+			typ = thriftFalse
+		}
+		if delta := id - last; delta > 0 && delta <= 15 {
+			e.buf = append(e.buf, byte(delta<<4)|typ)
+		} else {
+			e.buf = append(e.buf, typ)
+			e.varint(int64(id))
+		}
+		last = id
+		if _, ok := val.(bool); ok {
+			continue
+		}
+		if err := e.writeValue(val); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, 0) // stop
+	return nil
+}
+
+func (e *thriftEncoder) writeValue(val interface{}) error {
+	switch v := val.(type) {
+	case bool: // only reached for list elements, struct fields carry booleans in their header
+		if v {
+			e.buf = append(e.buf, thriftTrue)
+		} else {
+			e.buf = append(e.buf, thriftFalse)
+		}
+	case int8:
+		e.buf = append(e.buf, byte(v))
+	case int16:
+		e.varint(int64(v))
+	case int32:
+		e.varint(int64(v))
+	case int64:
+		e.varint(v)
+	case float64:
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+	case string:
+		e.uvarint(uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	case []byte:
+		e.uvarint(uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	case thriftListOf:
+		if len(v.items) < 15 {
+			e.buf = append(e.buf, byte(len(v.items)<<4)|v.elem)
+		} else {
+			e.buf = append(e.buf, 0xf0|v.elem)
+			e.uvarint(uint64(len(v.items)))
+		}
+		for _, item := range v.items {
+			if err := e.writeValue(item); err != nil {
+				return err
+			}
+		}
+	case thriftStruct:
+		return e.writeStruct(v)
+	default:
+		return fmt.Errorf("Cannot encode %T as thrift", val)
+	}
+	return nil
+}
+
+// thriftType returns the compact protocol type code of a value to encode, or 0 if it cannot be encoded
+func thriftType(val interface{}) byte {
+	switch val.(type) {
+	case bool:
+		return thriftTrue
+	case int8:
+		return thriftByte
+	case int16:
+		return thriftI16
+	case int32:
+		return thriftI32
+	case int64:
+		return thriftI64
+	case float64:
+		return thriftDouble
+	case string, []byte:
+		return thriftBinary
+	case thriftListOf:
+		return thriftList
+	case thriftStruct:
+		return thriftStruc
+	}
+	return 0
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsx* types mirror the parts of the OOXML SpreadsheetML schema that are read and written
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.T
+	}
+	var b strings.Builder
+	for _, run := range rt.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string       `xml:"r,attr"`
+			S  int          `xml:"s,attr"`
+			T  string       `xml:"t,attr"`
+			V  string       `xml:"v"`
+			Is xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// FromXLSX creates a Table from a worksheet of an .xlsx file. If sheet is empty, the first worksheet is read. header and index behave as in FromSlice. Numbers become int when they are whole and float64 otherwise, cells with a date number format become time.Time, booleans become bool and empty cells are nil
+func FromXLSX(filePath string, sheet string, header bool, index bool) (*Table, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var wb xlsxWorkbook
+	if err := readXLSXPart(files, "xl/workbook.xml", &wb, true); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels, true); err != nil {
+		return nil, err
+	}
+	var sst xlsxSharedStrings
+	if err := readXLSXPart(files, "xl/sharedStrings.xml", &sst, false); err != nil {
+		return nil, err
+	}
+	var styles xlsxStyles
+	if err := readXLSXPart(files, "xl/styles.xml", &styles, false); err != nil {
+		return nil, err
+	}
+
+	if len(wb.Sheets) == 0 {
+		return nil, errors.New("Workbook has no sheets")
+	}
+	rid := wb.Sheets[0].RID
+	if sheet != "" {
+		rid = ""
+		for _, s := range wb.Sheets {
+			if s.Name == sheet {
+				rid = s.RID
+			}
+		}
+		if rid == "" {
+			return nil, fmt.Errorf("Sheet %q not found", sheet)
+		}
+	}
+	var target string
+	for _, rel := range rels.Relationships {
+		if rel.ID == rid {
+			target = rel.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var ws xlsxWorksheet
+	if err := readXLSXPart(files, target, &ws, true); err != nil {
+		return nil, err
+	}
+
+	dateStyles := make([]bool, len(styles.CellXfs))
+	customFmts := make(map[int]string)
+	for _, numFmt := range styles.NumFmts {
+		customFmts[numFmt.ID] = numFmt.Code
+	}
+	for i, xf := range styles.CellXfs {
+		dateStyles[i] = isDateFormat(xf.NumFmtID, customFmts[xf.NumFmtID])
+	}
+
+	var grid [][]interface{}
+	var width int
+	for i, row := range ws.Rows {
+		r := row.R - 1
+		if row.R == 0 {
+			r = i
+		}
+		for r >= len(grid) {
+			grid = append(grid, nil)
+		}
+		for j, cell := range row.Cells {
+			c := j
+			if cell.R != "" {
+				if c, err = xlsxColumn(cell.R); err != nil {
+					return nil, err
+				}
+			}
+			for c >= len(grid[r]) {
+				grid[r] = append(grid[r], nil)
+			}
+			isDate := cell.S < len(dateStyles) && dateStyles[cell.S]
+			if grid[r][c], err = xlsxValue(cell.T, cell.V, cell.Is, sst, isDate, wb.WorkbookPr.Date1904); err != nil {
+				return nil, fmt.Errorf("Cell %s: %v", cell.R, err)
+			}
+		}
+		if len(grid[r]) > width {
+			width = len(grid[r])
+		}
+	}
+	if len(grid) == 0 {
+		return nil, errors.New("Sheet is empty")
+	}
+	for i := range grid {
+		for len(grid[i]) < width {
+			grid[i] = append(grid[i], nil)
+		}
+	}
+
+	return FromSlice(interface2D{&grid}, header, index), nil
+}
+
+// readXLSXPart decodes an xml part of the package into v. Missing optional parts are skipped
+func readXLSXPart(files map[string]*zip.File, name string, v interface{}, required bool) error {
+	f, ok := files[name]
+	if !ok {
+		if required {
+			return fmt.Errorf("Missing %s", name)
+		}
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// xlsxValue converts the raw contents of a cell to a Go value
+func xlsxValue(typ string, raw string, is xlsxRichText, sst xlsxSharedStrings, isDate bool, date1904 bool) (interface{}, error) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(raw)
+		if err != nil || i < 0 || i >= len(sst.Items) {
+			return nil, errors.New("Invalid shared string index")
+		}
+		return sst.Items[i].String(), nil
+	case "inlineStr":
+		return is.String(), nil
+	case "str", "e":
+		return raw, nil
+	case "b":
+		return raw == "1", nil
+	}
+
+	if raw == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	if isDate {
+		return xlsxTime(f, date1904), nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f), nil
+	}
+	return f, nil
+}
+
+// xlsxColumn returns the zero based column of a cell reference ie. "B3" -> 1
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	var i int
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("Invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// xlsxColumnName returns the letters of a zero based column ie. 27 -> "AB"
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// isDateFormat reports whether a number format displays dates or times
+func isDateFormat(id int, code string) bool {
+	if (id >= 14 && id <= 22) || (id >= 45 && id <= 47) {
+		return true
+	}
+	if code == "" {
+		return false
+	}
+	var quoted, bracket bool
+	for _, r := range strings.ToLower(code) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case bracket:
+		case strings.ContainsRune("dmyhs", r):
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	xlsxEpoch     = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	xlsxEpoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// xlsxTime converts a serial date number to a time in UTC, rounded to the millisecond
+func xlsxTime(serial float64, date1904 bool) time.Time {
+	epoch := xlsxEpoch
+	if date1904 {
+		epoch = xlsxEpoch1904
+	}
+	days := math.Floor(serial)
+	ms := math.Round((serial - days) * 24 * 60 * 60 * 1000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// xlsxSerial converts the wall clock of a time to a serial date number
+func xlsxSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Sub(xlsxEpoch)) / float64(24*time.Hour)
+}
+
+// ToXLSX writes one or more tables to an .xlsx file with a worksheet per table, ordered by sheet name. The first row of each worksheet holds the index header and column names and the first column holds the index. Numbers and bools are written as such, except infinite floats which are written as text and NaN which is left empty like other missing values, times are written as dates and every other value is written as text using DefaultFormats
+func ToXLSX(filePath string, sheets map[string]*Table) error {
+	if len(sheets) == 0 {
+		return errors.New("No sheets to write")
+	}
+	names := make([]string, 0, len(sheets))
+	for name := range sheets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(file)
+
+	var contentTypes, workbook, rels strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, name := range names {
+		part := fmt.Sprintf("worksheets/sheet%d.xml", i+1)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/%s" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, part)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="%s"/>`, i+1, part)
+
+		w, err := zw.Create("xl/" + part)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if err := writeXLSXSheet(w, sheets[name]); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(names)+1)
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		// style 1 is a date and style 2 a date with time
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+			`</styleSheet>`},
+	}
+	for _, p := range parts {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if _, err := io.WriteString(w, p.body); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeXLSXSheet writes the worksheet part of a table
+func writeXLSXSheet(w io.Writer, t *Table) error {
+	ew := &errWriter{w: w}
+	ew.printf("%s<worksheet xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\"><sheetData>", xml.Header)
+
+	rows := append([][]interface{}{mergeIndex1D(t.Index.Header, t.Header.Slice)}, mergeIndex2D(t.Index.Slice, t.Vals)...)
+	for i, row := range rows {
+		ew.printf(`<row r="%d">`, i+1)
+		for j, cell := range row {
+			if IsNA(cell) {
+				continue
+			}
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			switch v := cell.(type) {
+			case bool:
+				b := 0
+				if v {
+					b = 1
+				}
+				ew.printf(`<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+			case time.Time:
+				style := 2
+				if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+					style = 1
+				}
+				ew.printf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(xlsxSerial(v), 'f', -1, 64))
+			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+				if f, _ := toFloat(v); math.IsInf(f, 0) { // not a valid number in a cell, so written as text
+					xlsxInlineString(ew, ref, DefaultFormats.Format(nil, v))
+					continue
+				}
+				ew.printf(`<c r="%s"><v>%s</v></c>`, ref, defaultFormat(v))
+			default:
+				xlsxInlineString(ew, ref, DefaultFormats.Format(nil, v))
+			}
+		}
+		ew.printf(`</row>`)
+	}
+	ew.printf(`</sheetData></worksheet>`)
+	return ew.err
+}
+
+// xlsxInlineString writes a text cell
+func xlsxInlineString(ew *errWriter, ref, s string) {
+	ew.printf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(s))
+}
+
+// xmlEscape escapes text for use in xml content and attributes
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}